@@ -6,5 +6,17 @@
 # #gazelle:map_kind web_assets web_assets @my_local_repo
 
 load("//internal:web_assets.bzl", _web_assets = "web_assets")
+load("//internal:e2e_test.bzl", _cypress_test = "cypress_test", _playwright_test = "playwright_test")
+load("//internal:storybook_stories.bzl", _storybook_stories = "storybook_stories")
+load("//internal:css_module.bzl", _css_module = "css_module")
+load("//internal:sfc.bzl", _svelte_component = "svelte_component", _vue_component = "vue_component")
+load("//internal:mdx_document.bzl", _mdx_document = "mdx_document")
 web_assets = _web_assets
 web_asset = _web_assets
+playwright_test = _playwright_test
+cypress_test = _cypress_test
+storybook_stories = _storybook_stories
+css_module = _css_module
+vue_component = _vue_component
+svelte_component = _svelte_component
+mdx_document = _mdx_document
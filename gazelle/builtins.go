@@ -0,0 +1,138 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"strings"
+)
+
+// nodeBuiltins maps the Node.js builtin modules to the major version of
+// Node.js adding them, see https://nodejs.org/api/modules.html#built-in-modules
+var nodeBuiltins = map[string]int{
+	"assert":              0,
+	"assert/strict":       15,
+	"async_hooks":         8,
+	"buffer":              0,
+	"child_process":       0,
+	"cluster":             0,
+	"console":             0,
+	"constants":           0,
+	"crypto":              0,
+	"dgram":               0,
+	"diagnostics_channel": 15,
+	"dns":                 0,
+	"dns/promises":        15,
+	"domain":              0,
+	"events":              0,
+	"fs":                  0,
+	"fs/promises":         14,
+	"http":                0,
+	"http2":               8,
+	"https":               0,
+	"inspector":           8,
+	"inspector/promises":  19,
+	"module":              0,
+	"net":                 0,
+	"os":                  0,
+	"path":                0,
+	"path/posix":          15,
+	"path/win32":          15,
+	"perf_hooks":          8,
+	"process":             0,
+	"punycode":            0,
+	"querystring":         0,
+	"readline":            0,
+	"readline/promises":   17,
+	"repl":                0,
+	"stream":              0,
+	"stream/consumers":    16,
+	"stream/promises":     15,
+	"stream/web":          16,
+	"string_decoder":      0,
+	"sys":                 0,
+	"timers":              0,
+	"timers/promises":     15,
+	"tls":                 0,
+	"trace_events":        10,
+	"tty":                 0,
+	"url":                 0,
+	"util":                0,
+	"util/types":          15,
+	"v8":                  0,
+	"vm":                  0,
+	"wasi":                13,
+	"worker_threads":      10,
+	"zlib":                0,
+}
+
+// nodePrefixedBuiltins are the Node.js builtin modules that can only be
+// imported with the node: prefix, ie. "node:test"
+var nodePrefixedBuiltins = map[string]int{
+	"sea":            20,
+	"sqlite":         22,
+	"test":           18,
+	"test/reporters": 19,
+}
+
+// runtimeTypes are the npm packages declaring the modules of each runtime
+var runtimeTypes = map[string]string{
+	"node": "@types/node",
+	"bun":  "@types/bun",
+}
+
+// isBuiltin reports whether imp is a module of the runtime rather than a
+// file or an npm package, and returns the npm package declaring its types.
+// Node.js builtins are limited to the ones of js_node_version. Imports with
+// one of the js_builtin_prefixes, like "node:" or "bun:", are builtins,
+// except for the Node.js modules that do not exist in js_node_version. A
+// prefix without a trailing colon, like "bun", matches a module and its
+// subpaths.
+func isBuiltin(imp string, jsConfig *JsConfig) (string, bool) {
+
+	for _, prefix := range jsConfig.BuiltinPrefixes {
+		runtime, isScheme := strings.CutSuffix(prefix, ":")
+		if isScheme && !strings.HasPrefix(imp, prefix) {
+			continue
+		}
+		if !isScheme && imp != prefix && !strings.HasPrefix(imp, prefix+"/") {
+			continue
+		}
+		if runtime == "node" && isScheme {
+			name := strings.TrimPrefix(imp, prefix)
+			version, ok := nodeBuiltins[name]
+			if !ok {
+				version, ok = nodePrefixedBuiltins[name]
+			}
+			if ok && !hasNodeVersion(version, jsConfig) {
+				return "", false
+			}
+		}
+		return runtimeTypes[runtime], true
+	}
+
+	if version, ok := nodeBuiltins[imp]; ok && hasNodeVersion(version, jsConfig) {
+		return runtimeTypes["node"], true
+	}
+
+	return "", false
+}
+
+// hasNodeVersion reports whether a builtin module added in version exists in
+// js_node_version, which defaults to the latest version.
+func hasNodeVersion(version int, jsConfig *JsConfig) bool {
+	return jsConfig.NodeVersion <= 0 || version <= jsConfig.NodeVersion
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"testing"
+)
+
+func TestIsBuiltin(t *testing.T) {
+	for _, tc := range []struct {
+		desc, imp   string
+		nodeVersion int
+		prefixes    []string
+		types       string
+		builtin     bool
+	}{
+		{
+			desc:    "builtin",
+			imp:     "fs",
+			types:   "@types/node",
+			builtin: true,
+		}, {
+			desc:    "subpath",
+			imp:     "fs/promises",
+			types:   "@types/node",
+			builtin: true,
+		}, {
+			desc:    "unknown subpath",
+			imp:     "fs/extra",
+			builtin: false,
+		}, {
+			desc:    "npm package",
+			imp:     "lodash",
+			builtin: false,
+		}, {
+			desc:    "node prefix",
+			imp:     "node:stream/web",
+			types:   "@types/node",
+			builtin: true,
+		}, {
+			desc:    "prefix only",
+			imp:     "test",
+			builtin: false,
+		}, {
+			desc:    "prefix only with prefix",
+			imp:     "node:test",
+			types:   "@types/node",
+			builtin: true,
+		}, {
+			desc:        "older node version",
+			imp:         "stream/web",
+			nodeVersion: 14,
+			builtin:     false,
+		}, {
+			desc:        "older node version with prefix",
+			imp:         "node:sqlite",
+			nodeVersion: 20,
+			builtin:     false,
+		}, {
+			desc:        "newer node version",
+			imp:         "diagnostics_channel",
+			nodeVersion: 18,
+			types:       "@types/node",
+			builtin:     true,
+		}, {
+			desc:     "bun prefix",
+			imp:      "bun:sqlite",
+			prefixes: []string{"node:", "bun:"},
+			types:    "@types/bun",
+			builtin:  true,
+		}, {
+			desc:     "bun module",
+			imp:      "bun",
+			prefixes: []string{"bun:", "bun"},
+			types:    "@types/bun",
+			builtin:  true,
+		}, {
+			desc:     "bun module prefix",
+			imp:      "bunyan",
+			prefixes: []string{"bun:", "bun"},
+			builtin:  false,
+		}, {
+			desc:     "no node prefix",
+			imp:      "node:fs",
+			prefixes: []string{},
+			builtin:  false,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			jsConfig := NewJsConfig()
+			jsConfig.NodeVersion = tc.nodeVersion
+			if tc.prefixes != nil {
+				jsConfig.BuiltinPrefixes = tc.prefixes
+			}
+			types, builtin := isBuiltin(tc.imp, jsConfig)
+			if builtin != tc.builtin || types != tc.types {
+				t.Errorf("expected %q, %v, got %q, %v", tc.types, tc.builtin, types, builtin)
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -54,13 +55,29 @@ func (c *JsConfigs) ParentForPackage(pkg string) *JsConfig {
 type JsConfig struct {
 	Enabled         bool
 	PackageFile     string
+	PackageDir      string
+	AutoPackageFile bool
 	NpmDependencies struct {
 		Dependencies    map[string]string `json:"dependencies"`
 		DevDependencies map[string]string `json:"devDependencies"`
 	}
+	Lockfile           *Lockfile
+	HoistPatterns      []string
 	LookupTypes        bool
+	BundledTypes       bool
+	NodeVersion        int
+	BuiltinPrefixes    []string
 	ImportAliases      []struct{ From, To string }
 	ImportAliasPattern *regexp.Regexp
+	IgnoreImports      []string
+	ResolvePatterns    []struct{ Pattern, Label string }
+	ExternalRepos      []ExternalRepo
+	DependencyGraph    string
+	ImportCycles       string
+	DenyDeps           []string
+	AllowDeps          []string
+	BoundaryViolations string
+	AutoVisibility     bool
 	Visibility         Visibility
 	CollectBarrels     bool
 	CollectWebAssets   bool
@@ -80,12 +97,15 @@ type JsConfig struct {
 	JestConfig         string
 	JestTestsPerShard  int
 	JestSize           string
+	PlaywrightConfig   string
+	CypressConfig      string
 }
 
 func NewJsConfig() *JsConfig {
 	return &JsConfig{
-		Enabled:     true,
-		PackageFile: "package.json",
+		Enabled:         true,
+		PackageFile:     "package.json",
+		AutoPackageFile: false,
 		NpmDependencies: struct {
 			Dependencies    map[string]string "json:\"dependencies\""
 			DevDependencies map[string]string "json:\"devDependencies\""
@@ -93,9 +113,20 @@ func NewJsConfig() *JsConfig {
 			Dependencies:    make(map[string]string),
 			DevDependencies: make(map[string]string),
 		},
+		HoistPatterns:      []string{},
 		LookupTypes:        true,
+		NodeVersion:        0,
+		BuiltinPrefixes:    []string{"node:"},
 		ImportAliases:      []struct{ From, To string }{},
 		ImportAliasPattern: regexp.MustCompile("$^"),
+		IgnoreImports:      []string{},
+		ResolvePatterns:    []struct{ Pattern, Label string }{},
+		ExternalRepos:      []ExternalRepo{},
+		ImportCycles:       "off",
+		DenyDeps:           []string{},
+		AllowDeps:          []string{},
+		BoundaryViolations: "warn",
+		AutoVisibility:     false,
 		Visibility: Visibility{
 			Labels: []string{},
 		},
@@ -116,6 +147,8 @@ func NewJsConfig() *JsConfig {
 		DefaultNpmLabel:   "//:node_modules/",
 		JestTestsPerShard: -1,
 		JestConfig:        "",
+		PlaywrightConfig:  "",
+		CypressConfig:     "",
 	}
 }
 
@@ -128,6 +161,8 @@ func (parent *JsConfig) NewChild() *JsConfig {
 	child.Enabled = parent.Enabled
 
 	child.PackageFile = parent.PackageFile
+	child.PackageDir = parent.PackageDir
+	child.AutoPackageFile = parent.AutoPackageFile
 
 	// copy maps
 	child.NpmDependencies = struct {
@@ -144,7 +179,15 @@ func (parent *JsConfig) NewChild() *JsConfig {
 		child.NpmDependencies.DevDependencies[k] = v
 	}
 
+	child.Lockfile = parent.Lockfile
+	child.HoistPatterns = make([]string, len(parent.HoistPatterns)) // copy slice
+	copy(child.HoistPatterns, parent.HoistPatterns)
+
 	child.LookupTypes = parent.LookupTypes
+	child.BundledTypes = parent.BundledTypes
+	child.NodeVersion = parent.NodeVersion
+	child.BuiltinPrefixes = make([]string, len(parent.BuiltinPrefixes)) // copy slice
+	copy(child.BuiltinPrefixes, parent.BuiltinPrefixes)
 	child.ImportAliases = parent.ImportAliases
 	child.ImportAliases = make([]struct{ From, To string }, len(parent.ImportAliases)) // copy slice
 	for i := range parent.ImportAliases {
@@ -152,6 +195,21 @@ func (parent *JsConfig) NewChild() *JsConfig {
 	}
 	child.ImportAliasPattern = parent.ImportAliasPattern // Regenerated on change to ImportAliases
 
+	child.IgnoreImports = make([]string, len(parent.IgnoreImports)) // copy slice
+	copy(child.IgnoreImports, parent.IgnoreImports)
+	child.ResolvePatterns = make([]struct{ Pattern, Label string }, len(parent.ResolvePatterns)) // copy slice
+	copy(child.ResolvePatterns, parent.ResolvePatterns)
+	child.ExternalRepos = make([]ExternalRepo, len(parent.ExternalRepos)) // copy slice
+	copy(child.ExternalRepos, parent.ExternalRepos)
+	child.DependencyGraph = parent.DependencyGraph
+	child.ImportCycles = parent.ImportCycles
+	child.DenyDeps = make([]string, len(parent.DenyDeps)) // copy slice
+	copy(child.DenyDeps, parent.DenyDeps)
+	child.AllowDeps = make([]string, len(parent.AllowDeps)) // copy slice
+	copy(child.AllowDeps, parent.AllowDeps)
+	child.BoundaryViolations = parent.BoundaryViolations
+	child.AutoVisibility = parent.AutoVisibility
+
 	child.Visibility = Visibility{
 		Labels: make([]string, len(parent.Visibility.Labels)), // copy slice
 	}
@@ -173,6 +231,8 @@ func (parent *JsConfig) NewChild() *JsConfig {
 	child.JestTestsPerShard = parent.JestTestsPerShard
 	child.JestSize = parent.JestSize
 	child.JestConfig = parent.JestConfig
+	child.PlaywrightConfig = parent.PlaywrightConfig
+	child.CypressConfig = parent.CypressConfig
 
 	child.JSRoot = parent.JSRoot
 	child.WebAssetSuffixes = make(map[string]bool) // copy map
@@ -186,6 +246,59 @@ func (parent *JsConfig) NewChild() *JsConfig {
 	return child
 }
 
+// readPackageFile reads the dependencies and devDependencies of the
+// package.json packageFile, relative to the package pkg. Imports of these npm
+// packages resolve to npmLabel.
+func (jsConfig *JsConfig) readPackageFile(repoRoot string, pkg string, packageFile string, npmLabel string) error {
+
+	if !strings.HasSuffix(npmLabel, ":") && !strings.HasSuffix(npmLabel, "/") {
+		npmLabel += "/"
+	}
+
+	data, err := os.ReadFile(path.Join(repoRoot, pkg, packageFile))
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", packageFile, err)
+	}
+
+	// Read dependencies from file
+	newDeps := struct {
+		Dependencies    map[string]string "json:\"dependencies\""
+		DevDependencies map[string]string "json:\"devDependencies\""
+	}{
+		Dependencies:    make(map[string]string),
+		DevDependencies: make(map[string]string),
+	}
+	if err := json.Unmarshal(data, &newDeps); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", packageFile, err)
+	}
+
+	jsConfig.PackageFile = packageFile
+	jsConfig.PackageDir = path.Dir(path.Join(pkg, packageFile))
+
+	// Store npmLabel in dependencies
+	for k := range newDeps.Dependencies {
+		jsConfig.NpmDependencies.Dependencies[k] = npmLabel
+	}
+	for k := range newDeps.DevDependencies {
+		jsConfig.NpmDependencies.DevDependencies[k] = npmLabel
+	}
+
+	return nil
+}
+
+// ExternalRepo maps the imports starting with Prefix to the rules of another
+// Bazel repository, whose BUILD files follow the conventions of this extension.
+type ExternalRepo struct {
+	Prefix string
+	// Repo is the name of the external repository
+	Repo string
+	// Root is the package of the external repository Prefix refers to
+	Root string
+	// PerPackage is true when each directory has a single rule, instead of a
+	// rule per file
+	PerPackage bool
+}
+
 type Visibility struct {
 	Labels []string
 }
@@ -220,9 +333,37 @@ func (lang *JS) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
 // This is called once with the root configuration when Gazelle starts.
 // CheckFlags may set default values in flags or make implied changes.
 func (lang *JS) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	lang.coversRepo = coversRepo(fs, c)
+	if mode := fs.Lookup("mode"); mode != nil {
+		lang.writesFiles = mode.Value.String() == "fix"
+	}
 	return nil
 }
 
+// coversRepo reports whether gazelle visits every package of the repository,
+// ie. it runs recursively from the repository root.
+func coversRepo(fs *flag.FlagSet, c *config.Config) bool {
+	if recursive := fs.Lookup("r"); recursive != nil && recursive.Value.String() == "false" {
+		return false
+	}
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(c.WorkDir, dir)
+		}
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(c.RepoRoot, dir); err == nil && rel == "." {
+			return true
+		}
+	}
+	return false
+}
+
 // KnownDirectives returns a list of directive keys that this Configurer can
 // interpret. Gazelle prints errors for directives that are not recognized by
 // any Configurer.
@@ -231,10 +372,25 @@ func (*JS) KnownDirectives() []string {
 		"js_extension",
 		"js_root",
 		"js_lookup_types",
+		"js_bundled_types",
+		"js_node_version",
+		"js_builtin_prefixes",
 		"js_fix",
 		"js_package_file",
+		"js_auto_package_file",
+		"js_lockfile",
+		"js_lockfile_hoist",
 		"js_import_alias",
+		"js_ignore_imports",
+		"js_resolve",
+		"js_external_repo",
+		"js_dependency_graph",
+		"js_import_cycles",
+		"js_deny_deps",
+		"js_allow_deps",
+		"js_boundary_violations",
 		"js_visibility",
+		"js_auto_visibility",
 		"js_collect_barrels",
 		"js_aggregate_modules",
 		"js_collect_web_assets",
@@ -264,7 +420,7 @@ func (*JS) KnownDirectives() []string {
 //
 // f is the build file for the current directory or nil if there is no
 // existing build file.
-func (*JS) Configure(c *config.Config, rel string, f *rule.File) {
+func (lang *JS) Configure(c *config.Config, rel string, f *rule.File) {
 
 	// Create the root config.
 	if _, exists := c.Exts[languageName]; !exists {
@@ -280,6 +436,16 @@ func (*JS) Configure(c *config.Config, rel string, f *rule.File) {
 		jsConfigs[rel] = jsConfig
 	}
 
+	// Detect e2e runner configs, these apply to e2e tests in this directory and below
+	if configFile := findConfigFile(c.RepoRoot, rel, playwrightConfigFiles); configFile != "" {
+		jsConfig.PlaywrightConfig = configFile
+	}
+	if configFile := findConfigFile(c.RepoRoot, rel, cypressConfigFiles); configFile != "" {
+		jsConfig.CypressConfig = configFile
+	}
+
+	packageFileSet := false
+
 	// Read directives from existing file
 	if f != nil {
 
@@ -300,6 +466,29 @@ func (*JS) Configure(c *config.Config, rel string, f *rule.File) {
 			case "js_lookup_types":
 				jsConfig.LookupTypes = readBoolDirective(directive)
 
+			case "js_bundled_types":
+				jsConfig.BundledTypes = readBoolDirective(directive)
+
+			case "js_node_version":
+				// the major version matters, ie. "20" for "v20.11.0"
+				major, _, _ := strings.Cut(strings.TrimPrefix(directive.Value, "v"), ".")
+				if major == "" {
+					jsConfig.NodeVersion = 0
+					break
+				}
+				version, err := strconv.Atoi(major)
+				if err != nil {
+					log.Fatal(Err("failed to read directive %s %s: expected a Node.js version like 20", directive.Key, directive.Value))
+				}
+				jsConfig.NodeVersion = version
+
+			case "js_builtin_prefixes":
+				prefixes := strings.Fields(directive.Value)
+				if len(prefixes) == 0 {
+					jsConfig.BuiltinPrefixes = []string{}
+				}
+				jsConfig.BuiltinPrefixes = append(jsConfig.BuiltinPrefixes, prefixes...)
+
 			case "js_fix":
 				jsConfig.Fix = readBoolDirective(directive)
 
@@ -308,40 +497,43 @@ func (*JS) Configure(c *config.Config, rel string, f *rule.File) {
 				if len(values) != 2 {
 					log.Fatal(Err("failed to read directive %s: %s, expected 2 values", directive.Key, directive.Value))
 				}
-				jsConfig.PackageFile = values[0]
 				npmLabel := values[1]
 				if strings.HasPrefix(npmLabel, ":") {
 					npmLabel = labels.ParseRelative(npmLabel, f.Pkg).Format()
 				}
-				if !strings.HasSuffix(npmLabel, ":") && !strings.HasSuffix(npmLabel, "/") {
-					npmLabel += "/"
+				if err := jsConfig.readPackageFile(c.RepoRoot, f.Pkg, values[0], npmLabel); err != nil {
+					log.Fatal(Err("failed to read directive %s %s: %v", directive.Key, directive.Value, err))
 				}
+				packageFileSet = true
 
-				data, err := os.ReadFile(path.Join(c.RepoRoot, f.Pkg, jsConfig.PackageFile))
-				if err != nil {
-					log.Fatal(Err("failed to open %s: %v", directive.Value, err))
-				}
+			case "js_auto_package_file":
+				jsConfig.AutoPackageFile = readBoolDirective(directive)
 
-				// Read dependencies from file
-				newDeps := struct {
-					Dependencies    map[string]string "json:\"dependencies\""
-					DevDependencies map[string]string "json:\"devDependencies\""
-				}{
-					Dependencies:    make(map[string]string),
-					DevDependencies: make(map[string]string),
-				}
-				if err := json.Unmarshal(data, &newDeps); err != nil {
-					log.Fatal(Err("failed to parse %s: %v", directive.Value, err))
+			case "js_lockfile":
+				if directive.Value == "" {
+					jsConfig.Lockfile = nil
+					break
 				}
-
-				// Store npmLabel in dependencies
-				for k := range newDeps.Dependencies {
-					jsConfig.NpmDependencies.Dependencies[k] = npmLabel
+				lockfile, err := readLockfile(c.RepoRoot, path.Join(f.Pkg, directive.Value))
+				if err != nil {
+					log.Fatal(Err("failed to read directive %s %s: %v", directive.Key, directive.Value, err))
 				}
-				for k := range newDeps.DevDependencies {
-					jsConfig.NpmDependencies.DevDependencies[k] = npmLabel
+				jsConfig.Lockfile = lockfile
+				if !jsConfig.Quiet {
+					conflicts := lockfile.conflicts()
+					names := make([]string, 0, len(conflicts))
+					for name := range conflicts {
+						names = append(names, name)
+					}
+					sort.Strings(names)
+					for _, name := range names {
+						log.Print(Warn("[%s] %s is installed with different versions: %s", lockfile.Path, name, strings.Join(conflicts[name], ", ")))
+					}
 				}
 
+			case "js_lockfile_hoist":
+				jsConfig.HoistPatterns = readPatternsDirective(directive, jsConfig.HoistPatterns)
+
 			case "js_import_alias":
 				vals := strings.SplitN(directive.Value, " ", 2)
 				jsConfig.ImportAliases = append(jsConfig.ImportAliases, struct{ From, To string }{From: vals[0], To: strings.TrimSpace(vals[1])})
@@ -357,8 +549,81 @@ func (*JS) Configure(c *config.Config, rel string, f *rule.File) {
 					log.Fatal(Err("failed to parse %s: %v", directive.Value, err))
 				}
 
+			case "js_ignore_imports":
+				jsConfig.IgnoreImports = readPatternsDirective(directive, jsConfig.IgnoreImports)
+
+			case "js_resolve":
+				vals := strings.Fields(directive.Value)
+				if len(vals) != 2 {
+					log.Fatal(Err("failed to read directive %s %s: expected an import pattern and a label", directive.Key, directive.Value))
+				}
+				if _, err := path.Match(vals[0], ""); err != nil {
+					log.Fatal(Err("failed to read directive %s %s: %v", directive.Key, directive.Value, err))
+				}
+				jsConfig.ResolvePatterns = append(jsConfig.ResolvePatterns, struct{ Pattern, Label string }{
+					Pattern: vals[0],
+					Label:   labels.ParseRelative(vals[1], f.Pkg).Format(),
+				})
+
+			case "js_external_repo":
+				vals := strings.Fields(directive.Value)
+				if len(vals) < 2 || len(vals) > 3 {
+					log.Fatal(Err("failed to read directive %s %s: expected an import prefix, a repository and an optional file|package mode", directive.Key, directive.Value))
+				}
+				repoName, root, ok := strings.Cut(strings.TrimLeft(vals[1], "@"), "//")
+				if !ok || repoName == "" {
+					log.Fatal(Err("failed to read directive %s %s: expected a repository like @repo//path", directive.Key, directive.Value))
+				}
+				externalRepo := ExternalRepo{
+					Prefix: strings.TrimSuffix(vals[0], "/"),
+					Repo:   repoName,
+					Root:   strings.Trim(root, "/"),
+				}
+				if len(vals) == 3 {
+					switch vals[2] {
+					case "file":
+					case "package":
+						externalRepo.PerPackage = true
+					default:
+						log.Fatal(Err("failed to read directive %s %s: unknown mode %s, expected file or package", directive.Key, directive.Value, vals[2]))
+					}
+				}
+				jsConfig.ExternalRepos = append(jsConfig.ExternalRepos, externalRepo)
+
+			case "js_dependency_graph":
+				if directive.Value == "" {
+					jsConfig.DependencyGraph = ""
+				} else {
+					jsConfig.DependencyGraph = filepath.Join(c.RepoRoot, f.Pkg, directive.Value)
+				}
+
+			case "js_import_cycles":
+				switch directive.Value {
+				case "off", "warn", "error":
+					jsConfig.ImportCycles = directive.Value
+				default:
+					log.Fatal(Err("failed to read directive %s %s: expected off, warn or error", directive.Key, directive.Value))
+				}
+
+			case "js_deny_deps":
+				jsConfig.DenyDeps = readPatternsDirective(directive, jsConfig.DenyDeps)
+
+			case "js_allow_deps":
+				jsConfig.AllowDeps = readPatternsDirective(directive, jsConfig.AllowDeps)
+
+			case "js_boundary_violations":
+				switch directive.Value {
+				case "warn", "drop":
+					jsConfig.BoundaryViolations = directive.Value
+				default:
+					log.Fatal(Err("failed to read directive %s %s: expected warn or drop", directive.Key, directive.Value))
+				}
+
 			case "js_visibility":
 				jsConfig.Visibility.Set(directive.Value)
+
+			case "js_auto_visibility":
+				jsConfig.AutoVisibility = readBoolDirective(directive)
 			case "js_default_npm_label":
 				jsConfig.DefaultNpmLabel = directive.Value
 				if !strings.HasSuffix(jsConfig.DefaultNpmLabel, ":") && !strings.HasSuffix(jsConfig.DefaultNpmLabel, "/") {
@@ -449,6 +714,27 @@ func (*JS) Configure(c *config.Config, rel string, f *rule.File) {
 			}
 		}
 	}
+
+	// Workspace projects of the lockfile, or every directory with a
+	// package.json with js_auto_package_file, link their dependencies in their
+	// own node_modules
+	isWorkspace := jsConfig.Lockfile != nil && jsConfig.Lockfile.isWorkspace(rel)
+	if (jsConfig.AutoPackageFile || isWorkspace) && !packageFileSet {
+		if fileInfo, err := os.Stat(path.Join(c.RepoRoot, rel, "package.json")); err == nil && fileInfo.Mode().IsRegular() {
+			npmLabel := labels.ParseRelative(":node_modules", rel).Format()
+			if err := jsConfig.readPackageFile(c.RepoRoot, rel, "package.json", npmLabel); err != nil {
+				log.Fatal(Err("failed to read %s: %v", path.Join(rel, "package.json"), err))
+			}
+		}
+	}
+
+	// The dependency graph is only built for the features reading it
+	if jsConfig.DependencyGraph != "" || jsConfig.ImportCycles == "error" || (jsConfig.ImportCycles == "warn" && !jsConfig.Quiet) {
+		lang.graph.edges = true
+	}
+	if jsConfig.AutoVisibility {
+		lang.graph.consumers = true
+	}
 }
 
 var jsTestExtensions = []string{
@@ -461,6 +747,56 @@ var tsTestExtensions = []string{
 	".test.tsx",
 }
 
+var playwrightTestExtensions = []string{
+	".e2e.js",
+	".e2e.jsx",
+	".e2e.ts",
+	".e2e.tsx",
+}
+
+var cypressTestExtensions = []string{
+	".cy.js",
+	".cy.jsx",
+	".cy.ts",
+	".cy.tsx",
+}
+
+var storyExtensions = []string{
+	".stories.js",
+	".stories.jsx",
+	".stories.ts",
+	".stories.tsx",
+}
+
+// in the order sass and less look them up for imports without an extension
+var stylesheetExtensions = []string{
+	".scss",
+	".sass",
+	".css",
+	".less",
+}
+
+var cssModuleExtensions = []string{
+	".module.css",
+	".module.scss",
+	".module.sass",
+	".module.less",
+}
+
+var playwrightConfigFiles = []string{
+	"playwright.config.ts",
+	"playwright.config.js",
+	"playwright.config.mts",
+	"playwright.config.mjs",
+}
+
+var cypressConfigFiles = []string{
+	"cypress.config.ts",
+	"cypress.config.js",
+	"cypress.config.mts",
+	"cypress.config.mjs",
+}
+
 var tsExtensions = []string{
 	".ts",
 	".tsx",
@@ -471,15 +807,43 @@ var jsExtensions = []string{
 	".jsx",
 }
 
+var vueExtensions = []string{
+	".vue",
+}
+
+var svelteExtensions = []string{
+	".svelte",
+}
+
+var mdxExtensions = []string{
+	".mdx",
+}
+
 var jsTestExtensionsPattern *regexp.Regexp
 var tsTestExtensionsPattern *regexp.Regexp
 var tsExtensionsPattern *regexp.Regexp
 var jsExtensionsPattern *regexp.Regexp
+var playwrightTestExtensionsPattern *regexp.Regexp
+var cypressTestExtensionsPattern *regexp.Regexp
+var storyExtensionsPattern *regexp.Regexp
+var stylesheetExtensionsPattern *regexp.Regexp
+var cssModuleExtensionsPattern *regexp.Regexp
+var vueExtensionsPattern *regexp.Regexp
+var svelteExtensionsPattern *regexp.Regexp
+var mdxExtensionsPattern *regexp.Regexp
 
 func init() { tsTestExtensionsPattern = extensionPattern(tsTestExtensions) }
 func init() { jsTestExtensionsPattern = extensionPattern(jsTestExtensions) }
 func init() { tsExtensionsPattern = extensionPattern(tsExtensions) }
 func init() { jsExtensionsPattern = extensionPattern(jsExtensions) }
+func init() { playwrightTestExtensionsPattern = extensionPattern(playwrightTestExtensions) }
+func init() { cypressTestExtensionsPattern = extensionPattern(cypressTestExtensions) }
+func init() { storyExtensionsPattern = extensionPattern(storyExtensions) }
+func init() { stylesheetExtensionsPattern = extensionPattern(stylesheetExtensions) }
+func init() { cssModuleExtensionsPattern = extensionPattern(cssModuleExtensions) }
+func init() { vueExtensionsPattern = extensionPattern(vueExtensions) }
+func init() { svelteExtensionsPattern = extensionPattern(svelteExtensions) }
+func init() { mdxExtensionsPattern = extensionPattern(mdxExtensions) }
 
 func extensionPattern(extensions []string) *regexp.Regexp {
 	escaped := make([]string, len(extensions))
@@ -527,6 +891,38 @@ func isReactFile(baseName string) bool {
 	return reactFilePattern.MatchString(baseName)
 }
 
+func isVueFile(baseName string) bool {
+	return vueExtensionsPattern.MatchString(baseName)
+}
+
+func isSvelteFile(baseName string) bool {
+	return svelteExtensionsPattern.MatchString(baseName)
+}
+
+func isMDXFile(baseName string) bool {
+	return mdxExtensionsPattern.MatchString(baseName)
+}
+
+func isStylesheet(baseName string) bool {
+	return stylesheetExtensionsPattern.MatchString(baseName)
+}
+
+func isCSSModule(baseName string) bool {
+	return cssModuleExtensionsPattern.MatchString(baseName)
+}
+
+// findConfigFile returns the repository relative path of the first of
+// fileNames found in the rel directory, or "" if none of them exist.
+func findConfigFile(repoRoot string, rel string, fileNames []string) string {
+	for _, fileName := range fileNames {
+		fileInfo, err := os.Stat(path.Join(repoRoot, rel, fileName))
+		if err == nil && fileInfo.Mode().IsRegular() {
+			return path.Join(rel, fileName)
+		}
+	}
+	return ""
+}
+
 func readBoolDirective(directive rule.Directive) bool {
 	if directive.Value == "" {
 		return true
@@ -539,6 +935,22 @@ func readBoolDirective(directive rule.Directive) bool {
 	}
 }
 
+// readPatternsDirective appends the glob patterns of a directive to patterns.
+// A directive without a value resets the patterns inherited from parents.
+func readPatternsDirective(directive rule.Directive, patterns []string) []string {
+	values := strings.Fields(directive.Value)
+	if len(values) == 0 {
+		return []string{}
+	}
+	for _, pattern := range values {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Fatal(Err("failed to read directive %s %s: %v", directive.Key, pattern, err))
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
 func readIntDirective(directive rule.Directive) int {
 	if directive.Value == "" {
 		return -1
@@ -31,7 +31,15 @@ import (
 )
 
 type imports struct {
-	set map[string]bool
+	set   map[string]bool
+	globs []GlobImport
+	// types are the packages of /// <reference types="..." /> directives
+	types map[string]bool
+	// overrides are the labels of imports with a gazelle:resolve comment
+	overrides map[string]string
+	// runtimes are the packages compiled files import implicitly, ie. vue for
+	// .vue files, which are only resolved when they are npm dependencies
+	runtimes map[string]bool
 }
 
 var noImports = imports{
@@ -54,6 +62,26 @@ var webAssetRules = rule.LoadInfo{
 	Name:    "@com_github_benchsci_rules_nodejs_gazelle//:defs.bzl",
 	Symbols: []string{"web_assets"},
 }
+var e2eRules = rule.LoadInfo{
+	Name:    "@com_github_benchsci_rules_nodejs_gazelle//:defs.bzl",
+	Symbols: []string{"playwright_test", "cypress_test"},
+}
+var storybookRules = rule.LoadInfo{
+	Name:    "@com_github_benchsci_rules_nodejs_gazelle//:defs.bzl",
+	Symbols: []string{"storybook_stories"},
+}
+var cssModuleRules = rule.LoadInfo{
+	Name:    "@com_github_benchsci_rules_nodejs_gazelle//:defs.bzl",
+	Symbols: []string{"css_module"},
+}
+var sfcRules = rule.LoadInfo{
+	Name:    "@com_github_benchsci_rules_nodejs_gazelle//:defs.bzl",
+	Symbols: []string{"vue_component", "svelte_component"},
+}
+var mdxRules = rule.LoadInfo{
+	Name:    "@com_github_benchsci_rules_nodejs_gazelle//:defs.bzl",
+	Symbols: []string{"mdx_document"},
+}
 var managedRulesSet map[string]bool
 
 func init() {
@@ -70,6 +98,21 @@ func init() {
 	for _, rule := range webAssetRules.Symbols {
 		managedRulesSet[rule] = true
 	}
+	for _, rule := range e2eRules.Symbols {
+		managedRulesSet[rule] = true
+	}
+	for _, rule := range storybookRules.Symbols {
+		managedRulesSet[rule] = true
+	}
+	for _, rule := range cssModuleRules.Symbols {
+		managedRulesSet[rule] = true
+	}
+	for _, rule := range sfcRules.Symbols {
+		managedRulesSet[rule] = true
+	}
+	for _, rule := range mdxRules.Symbols {
+		managedRulesSet[rule] = true
+	}
 }
 
 // Loads returns .bzl files and symbols they define. Every rule generated by
@@ -81,6 +124,11 @@ func (lang *JS) Loads() []rule.LoadInfo {
 		tsRules,
 		jestRules,
 		webAssetRules,
+		e2eRules,
+		storybookRules,
+		cssModuleRules,
+		sfcRules,
+		mdxRules,
 	}
 }
 
@@ -108,6 +156,13 @@ func (lang *JS) GenerateRules(args language.GenerateArgs) language.GenerateResul
 	jsConfigs := args.Config.Exts[languageName].(JsConfigs)
 	jsConfig := jsConfigs[args.Rel]
 
+	// keep the existing build file, where rules are merged before visibility
+	// is computed from the resolved graph, and where hand-written rules may
+	// consume generated ones
+	if args.File != nil {
+		lang.files[args.Rel] = args.File
+	}
+
 	if !jsConfig.Enabled {
 		// ignore this directory
 		return language.GenerateResult{}
@@ -151,6 +206,36 @@ func (lang *JS) GenerateRules(args language.GenerateArgs) language.GenerateResul
 	generatedRules = append(generatedRules, generatedTestRules...)
 	generatedImports = append(generatedImports, generatedTestImports...)
 
+	// add "playwright_test" rule(s)
+	generatedPlaywrightRules, generatedPlaywrightImports := lang.genE2ETest(args, jsConfig, sources.playwrightSources, "playwright_test", "e2e", jsConfig.PlaywrightConfig)
+	generatedRules = append(generatedRules, generatedPlaywrightRules...)
+	generatedImports = append(generatedImports, generatedPlaywrightImports...)
+
+	// add "cypress_test" rule(s)
+	generatedCypressRules, generatedCypressImports := lang.genE2ETest(args, jsConfig, sources.cypressSources, "cypress_test", "cy", jsConfig.CypressConfig)
+	generatedRules = append(generatedRules, generatedCypressRules...)
+	generatedImports = append(generatedImports, generatedCypressImports...)
+
+	// add "storybook_stories" rule
+	generatedStoryRules, generatedStoryImports := lang.genStories(args, jsConfig, sources.storySources)
+	generatedRules = append(generatedRules, generatedStoryRules...)
+	generatedImports = append(generatedImports, generatedStoryImports...)
+
+	// add "vue_component" rule(s)
+	generatedVueRules, generatedVueImports := lang.genFileKindRules(args, jsConfig, pkgName, sources.vueSources, "vue_component", "vue")
+	generatedRules = append(generatedRules, generatedVueRules...)
+	generatedImports = append(generatedImports, generatedVueImports...)
+
+	// add "svelte_component" rule(s)
+	generatedSvelteRules, generatedSvelteImports := lang.genFileKindRules(args, jsConfig, pkgName, sources.svelteSources, "svelte_component", "svelte")
+	generatedRules = append(generatedRules, generatedSvelteRules...)
+	generatedImports = append(generatedImports, generatedSvelteImports...)
+
+	// add "mdx_document" rule(s)
+	generatedMDXRules, generatedMDXImports := lang.genFileKindRules(args, jsConfig, pkgName, sources.mdxSources, "mdx_document", "mdx")
+	generatedRules = append(generatedRules, generatedMDXRules...)
+	generatedImports = append(generatedImports, generatedMDXImports...)
+
 	appendTSExt := len(sources.jsSources) > 0
 
 	absJSRoot := path.Join(args.Config.RepoRoot, jsConfig.JSRoot)
@@ -211,6 +296,15 @@ func (lang *JS) GenerateRules(args language.GenerateArgs) language.GenerateResul
 	generatedRules = append(generatedRules, generatedWARules...)
 	generatedImports = append(generatedImports, generatedWAImports...)
 
+	// add "css_module" rule(s)
+	generatedCSSRules, generatedCSSImports := lang.genCSSModules(
+		args,
+		sources.cssModuleSources,
+		jsConfig,
+	)
+	generatedRules = append(generatedRules, generatedCSSRules...)
+	generatedImports = append(generatedImports, generatedCSSImports...)
+
 	// add "all_assets" "web_assets" rule
 	generatedAWARules, generatedAWAImports := lang.genAllAssets(
 		args,
@@ -238,17 +332,31 @@ func (lang *JS) GenerateRules(args language.GenerateArgs) language.GenerateResul
 }
 
 type collectedSources struct {
-	jestSources  []string
-	tsSources    []string
-	jsSources    []string
-	webAssetsSet map[string]bool
-	isBarrel     bool
+	jestSources       []string
+	playwrightSources []string
+	cypressSources    []string
+	storySources      []string
+	cssModuleSources  []string
+	vueSources        []string
+	svelteSources     []string
+	mdxSources        []string
+	tsSources         []string
+	jsSources         []string
+	webAssetsSet      map[string]bool
+	isBarrel          bool
 }
 
 func (lang *JS) collectSources(args language.GenerateArgs, jsConfig *JsConfig) collectedSources {
 
 	managedFiles := make(map[string]bool)
 	jestSources := []string{}
+	playwrightSources := []string{}
+	cypressSources := []string{}
+	storySources := []string{}
+	cssModuleSources := []string{}
+	vueSources := []string{}
+	svelteSources := []string{}
+	mdxSources := []string{}
 	tsSources := []string{}
 	jsSources := []string{}
 	webAssetsSet := make(map[string]bool)
@@ -262,6 +370,7 @@ func (lang *JS) collectSources(args language.GenerateArgs, jsConfig *JsConfig) c
 			"package-lock.json":   true,
 			"pnpm-lock.yaml":      true,
 			"pnpm-workspace.yaml": true,
+			"yarn.lock":           true,
 		}
 		if _, ignored := alwaysIgnoredFiles[baseName]; ignored {
 			continue
@@ -276,6 +385,22 @@ func (lang *JS) collectSources(args language.GenerateArgs, jsConfig *JsConfig) c
 			continue
 		}
 
+		// E2E TESTS
+		if playwrightTestExtensionsPattern.MatchString(baseName) {
+			playwrightSources = append(playwrightSources, baseName)
+			continue
+		}
+		if cypressTestExtensionsPattern.MatchString(baseName) {
+			cypressSources = append(cypressSources, baseName)
+			continue
+		}
+
+		// STORYBOOK STORIES
+		if storyExtensionsPattern.MatchString(baseName) {
+			storySources = append(storySources, baseName)
+			continue
+		}
+
 		// if the filename is like index.(jsx) then we assume we found a module
 		if isBarrelFile(baseName) {
 			isBarrel = true
@@ -294,6 +419,28 @@ func (lang *JS) collectSources(args language.GenerateArgs, jsConfig *JsConfig) c
 			continue
 		}
 
+		// VUE & SVELTE
+		if isVueFile(baseName) {
+			vueSources = append(vueSources, baseName)
+			continue
+		}
+		if isSvelteFile(baseName) {
+			svelteSources = append(svelteSources, baseName)
+			continue
+		}
+
+		// MDX
+		if isMDXFile(baseName) {
+			mdxSources = append(mdxSources, baseName)
+			continue
+		}
+
+		// CSS MODULES
+		if isCSSModule(baseName) {
+			cssModuleSources = append(cssModuleSources, baseName)
+			continue
+		}
+
 		// WEB ASSETS
 		if lang.isWebAsset(jsConfig, baseName) {
 			webAssetsSet[baseName] = true
@@ -302,11 +449,18 @@ func (lang *JS) collectSources(args language.GenerateArgs, jsConfig *JsConfig) c
 	}
 
 	return collectedSources{
-		jestSources:  jestSources,
-		tsSources:    tsSources,
-		jsSources:    jsSources,
-		webAssetsSet: webAssetsSet,
-		isBarrel:     isBarrel,
+		jestSources:       jestSources,
+		playwrightSources: playwrightSources,
+		cypressSources:    cypressSources,
+		storySources:      storySources,
+		cssModuleSources:  cssModuleSources,
+		vueSources:        vueSources,
+		svelteSources:     svelteSources,
+		mdxSources:        mdxSources,
+		tsSources:         tsSources,
+		jsSources:         jsSources,
+		webAssetsSet:      webAssetsSet,
+		isBarrel:          isBarrel,
 	}
 }
 
@@ -341,22 +495,147 @@ func readFileAndParse(filePath string, rel string) (*imports, int) {
 		fileImports.set["react"] = true
 	}
 
+	// Compiled single-file components import their framework's runtime
+	if isVueFile(filePath) {
+		fileImports.runtimes = map[string]bool{"vue": true}
+	}
+	if isSvelteFile(filePath) {
+		fileImports.runtimes = map[string]bool{"svelte": true}
+	}
+
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		log.Fatal(Err("Error reading %s: %v", filePath, err))
 	}
-	jsImports, testCount, err := ParseJS(data)
+	var result *ParseResult
+	if isVueFile(filePath) || isSvelteFile(filePath) {
+		result, err = ParseSFC(data)
+	} else if isMDXFile(filePath) {
+		result, err = ParseMDX(data)
+	} else {
+		result, err = ParseJS(data)
+	}
 	if err != nil {
 		log.Fatal(Err("Error parsing %s: %v", filePath, err))
 	}
-	for _, imp := range jsImports {
+	for _, imp := range result.Imports {
 		if rel != "" && strings.HasPrefix(imp, ".") {
 			imp = path.Join(rel, imp)
 		}
 		fileImports.set[imp] = true
 	}
 
-	return &fileImports, testCount
+	if len(result.Overrides) > 0 {
+		fileImports.overrides = make(map[string]string)
+		for imp, lbl := range result.Overrides {
+			if rel != "" && strings.HasPrefix(imp, ".") {
+				imp = path.Join(rel, imp)
+			}
+			fileImports.overrides[imp] = lbl
+		}
+	}
+
+	refPaths, refTypes := ParseReferences(data)
+	for _, ref := range refPaths {
+		if rel != "" && strings.HasPrefix(ref, ".") {
+			ref = path.Join(rel, ref)
+		}
+		fileImports.set[ref] = true
+	}
+	if len(refTypes) > 0 {
+		fileImports.types = make(map[string]bool)
+		for _, ref := range refTypes {
+			fileImports.types[ref] = true
+		}
+	}
+
+	for _, glob := range result.Globs {
+		if rel != "" && strings.HasPrefix(glob.Dir, ".") {
+			glob.Dir = path.Join(rel, glob.Dir)
+			excludes := make([]string, len(glob.Excludes))
+			for i, exclude := range glob.Excludes {
+				if strings.HasPrefix(exclude, ".") {
+					exclude = "./" + path.Join(rel, exclude)
+				}
+				excludes[i] = exclude
+			}
+			glob.Excludes = excludes
+		}
+		fileImports.globs = append(fileImports.globs, glob)
+	}
+
+	return &fileImports, result.JestTestCount
+}
+
+func (lang *JS) readStylesheetAndParse(filePath string, rel string, jsConfig *JsConfig) *imports {
+
+	fileImports := imports{
+		set: make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Fatal(Err("Error reading %s: %v", filePath, err))
+	}
+	stylesheets, assets := ParseStylesheet(data)
+
+	addImport := func(imp string, isImportedStylesheet bool) {
+		if strings.HasPrefix(imp, "~") {
+			// webpack style import from node_modules
+			fileImports.set[strings.TrimPrefix(imp, "~")] = true
+			return
+		}
+		if !strings.HasPrefix(imp, ".") {
+			// stylesheets reference files relative to themselves, unless it's a package
+			if isNpm, _, _ := lang.isNpmDependency(imp, jsConfig); isNpm {
+				fileImports.set[imp] = true
+				return
+			}
+			imp = "./" + imp
+		}
+		if isImportedStylesheet {
+			imp = findStylesheet(path.Dir(filePath), imp)
+		}
+		if rel != "" {
+			imp = path.Join(rel, imp)
+		}
+		fileImports.set[imp] = true
+	}
+	for _, imp := range stylesheets {
+		addImport(imp, true)
+	}
+	for _, imp := range assets {
+		addImport(imp, false)
+	}
+
+	return &fileImports
+}
+
+// findStylesheet returns the file a relative stylesheet import refers to,
+// following the sass and less conventions for partials, default extensions
+// and index files. The import is returned unchanged if no file matches.
+func findStylesheet(dir string, imp string) string {
+	dirName, baseName := path.Split(imp)
+	candidates := []string{imp, dirName + "_" + baseName}
+	if !isStylesheet(imp) {
+		candidates = []string{}
+		for _, ext := range stylesheetExtensions {
+			candidates = append(candidates, imp+ext, dirName+"_"+baseName+ext)
+		}
+		for _, ext := range stylesheetExtensions {
+			candidates = append(candidates, path.Join(imp, "index"+ext), path.Join(imp, "_index"+ext))
+		}
+	}
+	for _, candidate := range candidates {
+		fileInfo, err := os.Stat(path.Join(dir, candidate))
+		if err == nil && fileInfo.Mode().IsRegular() {
+			if !strings.HasPrefix(candidate, ".") {
+				candidate = "./" + candidate
+			}
+			return candidate
+		}
+	}
+	return imp
 }
 
 func (lang *JS) genPkgRule(args language.GenerateArgs, jsConfig *JsConfig) *rule.Rule {
@@ -394,9 +673,8 @@ func (lang *JS) genJestTest(args language.GenerateArgs, jsConfig *JsConfig, jest
 			imports, jestTestCount := readFileAndParse(filePath, "")
 
 			var collectedSnapshots []string
-			snapshotFile, err := os.Stat(path.Join(args.Dir, "__snapshots__", baseName+".snap"))
-			if err == nil && snapshotFile.Mode().IsRegular() {
-				collectedSnapshots = append(collectedSnapshots, path.Join("__snapshots__", baseName+".snap"))
+			if snapshot := findSnapshot(args.Dir, baseName); snapshot != "" {
+				collectedSnapshots = append(collectedSnapshots, snapshot)
 			}
 
 			lang.addJestAttributes(args, jsConfig, ruleName, r, jestTestCount, collectedSnapshots)
@@ -409,13 +687,20 @@ func (lang *JS) genJestTest(args language.GenerateArgs, jsConfig *JsConfig, jest
 		// Add all tests as a single rule
 		jestTestCount := 0
 		var allImports []imports
+		var collectedSnapshots []string
 		for _, baseName := range jestSources {
 			filePath := path.Join(args.Dir, baseName)
 			relativePart := path.Dir(baseName)
 			imps, tCount := readFileAndParse(filePath, relativePart)
 			jestTestCount += tCount
 			allImports = append(allImports, *imps)
+
+			// tests may live in nested folders, each with its own __snapshots__
+			if snapshot := findSnapshot(args.Dir, baseName); snapshot != "" {
+				collectedSnapshots = append(collectedSnapshots, snapshot)
+			}
 		}
+		sort.Strings(collectedSnapshots)
 		imports := flattenImports(allImports)
 
 		pkgName := PkgName(args.Rel)
@@ -425,12 +710,6 @@ func (lang *JS) genJestTest(args language.GenerateArgs, jsConfig *JsConfig, jest
 			ruleName,
 		)
 
-		var collectedSnapshots []string
-		snapshotDir, err := os.Stat(path.Join(args.Dir, "__snapshots__"))
-		if err == nil && snapshotDir.Mode().IsDir() {
-			collectedSnapshots = append(collectedSnapshots, "__snapshots__")
-		}
-
 		r.SetAttr("srcs", jestSources)
 		lang.addJestAttributes(args, jsConfig, ruleName, r, jestTestCount, collectedSnapshots)
 		generatedRules = append(generatedRules, r)
@@ -440,6 +719,17 @@ func (lang *JS) genJestTest(args language.GenerateArgs, jsConfig *JsConfig, jest
 	return generatedRules, generatedImports
 }
 
+// findSnapshot returns the path of the jest snapshot file written for the
+// test source src, relative to dir, or "" when the test has no snapshot file.
+func findSnapshot(dir string, src string) string {
+	snapshot := path.Join(path.Dir(src), "__snapshots__", path.Base(src)+".snap")
+	snapshotFile, err := os.Stat(path.Join(dir, snapshot))
+	if err == nil && snapshotFile.Mode().IsRegular() {
+		return snapshot
+	}
+	return ""
+}
+
 func (lang *JS) addJestAttributes(args language.GenerateArgs, jsConfig *JsConfig, baseName string, r *rule.Rule, jestTestCount int, collectedSnapshots []string) {
 	if jsConfig.JestConfig == "" && !jsConfig.Quiet {
 		log.Print(Warn("[%s/%s] no config for jest_test, use gazelle:js_jest_config directive", args.Rel, baseName))
@@ -464,6 +754,137 @@ func (lang *JS) addJestAttributes(args language.GenerateArgs, jsConfig *JsConfig
 	}
 }
 
+// genE2ETest generates end-to-end test rules of the given kind. Like jest_test,
+// there is one rule per test file, or one per package with js_collect_all.
+// The runner config is added to data later in resolve.go
+func (lang *JS) genE2ETest(args language.GenerateArgs, jsConfig *JsConfig, e2eSources []string, kind string, suffix string, configFile string) ([]*rule.Rule, []interface{}) {
+	generatedRules := make([]*rule.Rule, 0)
+	generatedImports := make([]interface{}, 0)
+
+	if len(e2eSources) > 0 && configFile == "" && !jsConfig.Quiet {
+		log.Print(Warn("[%s] no runner config found for %s in this package or its parents", args.Rel, kind))
+	}
+
+	if !jsConfig.CollectAll {
+		// Add each test as an individual rule
+		for _, baseName := range e2eSources {
+			ruleName := trimExt(baseName)
+			r := rule.NewRule(getKind(args.Config, kind), ruleName)
+			r.SetAttr("srcs", []string{baseName})
+			if len(jsConfig.Visibility.Labels) > 0 {
+				r.SetAttr("visibility", jsConfig.Visibility.Labels)
+			}
+
+			imports, _ := readFileAndParse(path.Join(args.Dir, baseName), "")
+
+			generatedRules = append(generatedRules, r)
+			generatedImports = append(generatedImports, imports)
+		}
+
+	} else if len(e2eSources) > 0 {
+		// Add all tests as a single rule
+		var allImports []imports
+		for _, baseName := range e2eSources {
+			imps, _ := readFileAndParse(path.Join(args.Dir, baseName), path.Dir(baseName))
+			allImports = append(allImports, *imps)
+		}
+
+		ruleName := fmt.Sprintf("%s_%s", PkgName(args.Rel), suffix)
+		r := rule.NewRule(getKind(args.Config, kind), ruleName)
+		r.SetAttr("srcs", e2eSources)
+		if len(jsConfig.Visibility.Labels) > 0 {
+			r.SetAttr("visibility", jsConfig.Visibility.Labels)
+		}
+
+		generatedRules = append(generatedRules, r)
+		generatedImports = append(generatedImports, flattenImports(allImports))
+	}
+
+	return generatedRules, generatedImports
+}
+
+// genStories groups the stories of a package into a single rule, keeping
+// storybook dependencies out of the rules of production sources.
+func (lang *JS) genStories(args language.GenerateArgs, jsConfig *JsConfig, storySources []string) ([]*rule.Rule, []interface{}) {
+	generatedRules := make([]*rule.Rule, 0)
+	generatedImports := make([]interface{}, 0)
+
+	if len(storySources) > 0 {
+		var allImports []imports
+		for _, baseName := range storySources {
+			relativePart := ""
+			if jsConfig.CollectAll {
+				relativePart = path.Dir(baseName)
+			}
+			imps, _ := readFileAndParse(path.Join(args.Dir, baseName), relativePart)
+			allImports = append(allImports, *imps)
+		}
+
+		ruleName := fmt.Sprintf("%s_stories", PkgName(args.Rel))
+		r := rule.NewRule(getKind(args.Config, "storybook_stories"), ruleName)
+		r.SetAttr("srcs", storySources)
+		if len(jsConfig.Visibility.Labels) > 0 {
+			r.SetAttr("visibility", jsConfig.Visibility.Labels)
+		}
+
+		generatedRules = append(generatedRules, r)
+		generatedImports = append(generatedImports, flattenImports(allImports))
+	}
+
+	return generatedRules, generatedImports
+}
+
+// genFileKindRules generates a rule of the given kind for each source, like
+// single-file components or MDX documents, or one rule per package with
+// js_collect_all.
+func (lang *JS) genFileKindRules(args language.GenerateArgs, jsConfig *JsConfig, pkgName string, sources []string, kind string, suffix string) ([]*rule.Rule, []interface{}) {
+	generatedRules := make([]*rule.Rule, 0)
+	generatedImports := make([]interface{}, 0)
+
+	if len(sources) == 0 {
+		return generatedRules, generatedImports
+	}
+
+	// Parse files to get imports
+	var imports []imports
+	for _, baseName := range sources {
+		relativePart := ""
+		if jsConfig.CollectAll {
+			relativePart = path.Dir(baseName)
+		}
+		imps, _ := readFileAndParse(path.Join(args.Dir, baseName), relativePart)
+		imports = append(imports, *imps)
+	}
+
+	if jsConfig.CollectAll {
+		// add as a folder
+		name := fmt.Sprintf("%s_%s", pkgName, suffix)
+		folderImports, folderRule := lang.makeFolderRule(moduleRuleArgs{
+			pkgName:  name,
+			cwd:      args.Rel,
+			ruleType: getKind(args.Config, kind),
+			srcs:     sources,
+			imports:  imports,
+		}, jsConfig)
+
+		generatedRules = append(generatedRules, folderRule)
+		generatedImports = append(generatedImports, folderImports)
+	} else {
+		// add as singletons
+		singletonRules := lang.makeRules(ruleArgs{
+			ruleType: getKind(args.Config, kind),
+			srcs:     sources,
+			trimExt:  false, // avoid clashes with a .ts file of the same name
+		}, jsConfig)
+		for i := range singletonRules {
+			generatedRules = append(generatedRules, singletonRules[i])
+			generatedImports = append(generatedImports, &imports[i])
+		}
+	}
+
+	return generatedRules, generatedImports
+}
+
 func (lang *JS) genRules(args language.GenerateArgs, jsConfig *JsConfig, isBarrel bool, isJSRoot bool, pkgName string, sources []string, appendTSExt bool, kind string) ([]*rule.Rule, []interface{}) {
 
 	// Parse files to get imports
@@ -742,6 +1163,25 @@ func flattenImports(imps []imports) *imports {
 		for k, v := range imps[i].set {
 			aggregatedImports.set[k] = v
 		}
+		aggregatedImports.globs = append(aggregatedImports.globs, imps[i].globs...)
+		for k, v := range imps[i].types {
+			if aggregatedImports.types == nil {
+				aggregatedImports.types = make(map[string]bool)
+			}
+			aggregatedImports.types[k] = v
+		}
+		for k, v := range imps[i].overrides {
+			if aggregatedImports.overrides == nil {
+				aggregatedImports.overrides = make(map[string]string)
+			}
+			aggregatedImports.overrides[k] = v
+		}
+		for k, v := range imps[i].runtimes {
+			if aggregatedImports.runtimes == nil {
+				aggregatedImports.runtimes = make(map[string]bool)
+			}
+			aggregatedImports.runtimes[k] = v
+		}
 	}
 
 	return &aggregatedImports
@@ -760,6 +1200,21 @@ func (lang *JS) genWebAssets(args language.GenerateArgs, webAssetsSet map[string
 	// always deterministic results
 	sort.Strings(webAssets)
 
+	// Parse stylesheets to get imports
+	var imports []imports
+	for _, baseName := range webAssets {
+		if !isStylesheet(baseName) {
+			imports = append(imports, noImports)
+			continue
+		}
+		relativePart := ""
+		if jsConfig.CollectAll {
+			relativePart = path.Dir(baseName)
+		}
+		imps := lang.readStylesheetAndParse(path.Join(args.Dir, baseName), relativePart, jsConfig)
+		imports = append(imports, *imps)
+	}
+
 	if len(webAssets) > 0 {
 		// Generate web_assets rule(s)
 
@@ -773,7 +1228,7 @@ func (lang *JS) genWebAssets(args language.GenerateArgs, webAssetsSet map[string
 			}
 
 			generatedRules = append(generatedRules, r)
-			generatedImports = append(generatedImports, &noImports)
+			generatedImports = append(generatedImports, flattenImports(imports))
 
 			// record all webAssets rules for all_assets rule later
 			fqName := fmt.Sprintf("//%s:%s", path.Join(args.Rel), name)
@@ -787,9 +1242,9 @@ func (lang *JS) genWebAssets(args language.GenerateArgs, webAssetsSet map[string
 				trimExt:  false, //shadow the original file name
 			}, jsConfig)
 
-			for _, r := range rules {
+			for i, r := range rules {
 				generatedRules = append(generatedRules, r)
-				generatedImports = append(generatedImports, &noImports)
+				generatedImports = append(generatedImports, &imports[i])
 
 				// record all webAssets rules for all_assets rule later
 				fqName := fmt.Sprintf("//%s:%s", path.Join(args.Rel), r.Name())
@@ -801,6 +1256,40 @@ func (lang *JS) genWebAssets(args language.GenerateArgs, webAssetsSet map[string
 	return generatedRules, generatedImports
 }
 
+// genCSSModules generates a rule for each CSS module. These rules provide
+// typings for the class names, so they are deps of the files importing them.
+func (lang *JS) genCSSModules(args language.GenerateArgs, cssModuleSources []string, jsConfig *JsConfig) ([]*rule.Rule, []interface{}) {
+
+	generatedRules := make([]*rule.Rule, 0)
+	generatedImports := make([]interface{}, 0)
+
+	// always deterministic results
+	sort.Strings(cssModuleSources)
+
+	rules := lang.makeRules(ruleArgs{
+		ruleType: getKind(args.Config, "css_module"),
+		srcs:     cssModuleSources,
+		trimExt:  false, //shadow the original file name
+	}, jsConfig)
+
+	for i, r := range rules {
+		relativePart := ""
+		if jsConfig.CollectAll {
+			relativePart = path.Dir(cssModuleSources[i])
+		}
+		imps := lang.readStylesheetAndParse(path.Join(args.Dir, cssModuleSources[i]), relativePart, jsConfig)
+
+		generatedRules = append(generatedRules, r)
+		generatedImports = append(generatedImports, imps)
+
+		// record all css_module rules for all_assets rule later
+		fqName := fmt.Sprintf("//%s:%s", path.Join(args.Rel), r.Name())
+		jsConfig.CollectedAssets[fqName] = true
+	}
+
+	return generatedRules, generatedImports
+}
+
 func (lang *JS) genAllAssets(args language.GenerateArgs, isJSRoot bool, jsConfig *JsConfig) ([]*rule.Rule, []interface{}) {
 	generatedRules := make([]*rule.Rule, 0)
 	generatedImports := make([]interface{}, 0)
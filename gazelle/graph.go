@@ -0,0 +1,538 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	bzl "github.com/bazelbuild/buildtools/build"
+)
+
+// dependencyGraph records the rules resolved by this extension, and the
+// imports behind each of their deps and data.
+type dependencyGraph struct {
+	nodes map[string]*graphNode
+
+	// edges is set when a package exports the graph or reports its import
+	// cycles, consumers when a package computes its visibility
+	edges     bool
+	consumers bool
+}
+
+type graphNode struct {
+	Label string   `json:"label"`
+	Kind  string   `json:"kind"`
+	Srcs  []string `json:"srcs"`
+
+	// export is the file the node is written to, if any
+	export string
+	// cycles is how import cycles through the node are reported
+	cycles string
+	edges  map[string]*graphEdge
+
+	// rule is the generated rule, when its visibility is computed from the
+	// packages consuming it
+	rule       *rule.Rule
+	pkg        string
+	visibility []string
+	consumers  map[string]bool
+}
+
+type graphEdge struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Attr    string   `json:"attr"`
+	Imports []string `json:"imports"`
+}
+
+func newDependencyGraph() *dependencyGraph {
+	return &dependencyGraph{
+		nodes: make(map[string]*graphNode),
+	}
+}
+
+func (g *dependencyGraph) node(lbl string) *graphNode {
+	n, ok := g.nodes[lbl]
+	if !ok {
+		n = &graphNode{
+			Label:     lbl,
+			Srcs:      []string{},
+			edges:     make(map[string]*graphEdge),
+			consumers: make(map[string]bool),
+		}
+		g.nodes[lbl] = n
+	}
+	return n
+}
+
+// addNode records a resolved rule, and the files it is made of.
+func (g *dependencyGraph) addNode(from label.Label, r *rule.Rule, jsConfig *JsConfig) {
+	if !g.edges && !g.consumers {
+		return
+	}
+	n := g.node(graphLabel(from, from.Repo))
+	n.Kind = r.Kind()
+	n.Srcs = []string{}
+	for _, src := range r.AttrStrings("srcs") {
+		n.Srcs = append(n.Srcs, path.Join(from.Pkg, src))
+	}
+	n.export = jsConfig.DependencyGraph
+	n.cycles = jsConfig.ImportCycles
+	if n.cycles == "warn" && jsConfig.Quiet {
+		// js_quiet silences warnings, cycles failing the run are still reported
+		n.cycles = "off"
+	}
+	n.pkg = from.Pkg
+}
+
+// addAutoVisibility records that the visibility of r is computed from the
+// packages consuming it, in addition to the labels of visibility.
+func (g *dependencyGraph) addAutoVisibility(from label.Label, r *rule.Rule, visibility []string) {
+	n := g.node(graphLabel(from, from.Repo))
+	n.rule = r
+	n.visibility = visibility
+}
+
+// addConsumer records that the package of from depends on dep.
+func (g *dependencyGraph) addConsumer(from label.Label, dep string) {
+	if !g.consumers {
+		return
+	}
+	lbl, err := label.Parse(dep)
+	if err != nil {
+		return
+	}
+	g.node(graphLabel(lbl.Abs(from.Repo, from.Pkg), from.Repo)).consumers[from.Pkg] = true
+}
+
+// addEdge records that imp caused dep to be added to the attr of from.
+func (g *dependencyGraph) addEdge(from label.Label, dep string, attr string, imp string) {
+	if !g.edges {
+		return
+	}
+	to := dep
+	if lbl, err := label.Parse(dep); err == nil {
+		to = graphLabel(lbl.Abs(from.Repo, from.Pkg), from.Repo)
+	}
+
+	n := g.node(graphLabel(from, from.Repo))
+	key := attr + " " + to
+	e, ok := n.edges[key]
+	if !ok {
+		e = &graphEdge{
+			From:    n.Label,
+			To:      to,
+			Attr:    attr,
+			Imports: []string{},
+		}
+		n.edges[key] = e
+	}
+	for _, existing := range e.Imports {
+		if existing == imp {
+			return
+		}
+	}
+	e.Imports = append(e.Imports, imp)
+	sort.Strings(e.Imports)
+}
+
+// graphLabel formats lbl as an absolute label, without the name of the main
+// repository.
+func graphLabel(lbl label.Label, repo string) string {
+	if lbl.Repo == repo {
+		lbl.Repo = ""
+	}
+	return lbl.String()
+}
+
+// sortedNodes returns the nodes exported to file, sorted by label.
+func (g *dependencyGraph) sortedNodes(file string) []*graphNode {
+	nodes := []*graphNode{}
+	for _, n := range g.nodes {
+		if n.export == file {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Label < nodes[j].Label
+	})
+	return nodes
+}
+
+func (n *graphNode) sortedEdges() []*graphEdge {
+	edges := make([]*graphEdge, 0, len(n.edges))
+	for _, e := range n.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Attr < edges[j].Attr
+	})
+	return edges
+}
+
+// findCycles returns the import cycles between the rules of the graph, as the
+// deps edges forming each cycle. There is one cycle per strongly connected
+// component, starting from its smallest label.
+func (g *dependencyGraph) findCycles() [][]*graphEdge {
+
+	labels := make([]string, 0, len(g.nodes))
+	for lbl := range g.nodes {
+		labels = append(labels, lbl)
+	}
+	sort.Strings(labels)
+
+	// Tarjan's strongly connected components algorithm
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := []string{}
+	components := [][]string{}
+
+	var connect func(lbl string)
+	connect = func(lbl string) {
+		index[lbl] = len(index)
+		lowLink[lbl] = index[lbl]
+		stack = append(stack, lbl)
+		onStack[lbl] = true
+
+		for _, e := range g.nodes[lbl].sortedEdges() {
+			if e.Attr != "deps" || g.nodes[e.To] == nil {
+				continue
+			}
+			if _, visited := index[e.To]; !visited {
+				connect(e.To)
+				lowLink[lbl] = min(lowLink[lbl], lowLink[e.To])
+			} else if onStack[e.To] {
+				lowLink[lbl] = min(lowLink[lbl], index[e.To])
+			}
+		}
+
+		if lowLink[lbl] == index[lbl] {
+			component := []string{}
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == lbl {
+					break
+				}
+			}
+			if len(component) > 1 {
+				components = append(components, component)
+			}
+		}
+	}
+	for _, lbl := range labels {
+		if _, visited := index[lbl]; !visited {
+			connect(lbl)
+		}
+	}
+
+	cycles := [][]*graphEdge{}
+	for _, component := range components {
+		sort.Strings(component)
+		cycles = append(cycles, g.shortestCycle(component))
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0].From < cycles[j][0].From
+	})
+	return cycles
+}
+
+// shortestCycle returns the shortest path of deps edges from the first node
+// of a strongly connected component back to itself.
+func (g *dependencyGraph) shortestCycle(component []string) []*graphEdge {
+
+	inComponent := make(map[string]bool)
+	for _, lbl := range component {
+		inComponent[lbl] = true
+	}
+
+	start := component[0]
+	via := make(map[string]*graphEdge)
+	queue := []string{start}
+	for len(queue) > 0 {
+		lbl := queue[0]
+		queue = queue[1:]
+		for _, e := range g.nodes[lbl].sortedEdges() {
+			if e.Attr != "deps" || !inComponent[e.To] || via[e.To] != nil {
+				continue
+			}
+			via[e.To] = e
+			if e.To == start {
+				queue = nil
+				break
+			}
+			queue = append(queue, e.To)
+		}
+	}
+
+	cycle := []*graphEdge{}
+	for e := via[start]; ; e = via[e.From] {
+		cycle = append([]*graphEdge{e}, cycle...)
+		if e.From == start {
+			break
+		}
+	}
+	return cycle
+}
+
+// cycleMode returns how a cycle is reported, the strictest js_import_cycles
+// of the rules it goes through.
+func (g *dependencyGraph) cycleMode(cycle []*graphEdge) string {
+	mode := "off"
+	for _, e := range cycle {
+		switch g.nodes[e.From].cycles {
+		case "error":
+			mode = "error"
+		case "warn":
+			if mode == "off" {
+				mode = "warn"
+			}
+		}
+	}
+	return mode
+}
+
+// reportCycles prints the import cycles between the rules of the graph, and
+// fails when one of them goes through a rule with js_import_cycles error.
+func (g *dependencyGraph) reportCycles() {
+
+	failed := false
+	for _, cycle := range g.findCycles() {
+		mode := g.cycleMode(cycle)
+		if mode == "off" {
+			continue
+		}
+
+		lines := []string{fmt.Sprintf("import cycle between %d targets:", len(cycle))}
+		for _, e := range cycle {
+			imports := make([]string, len(e.Imports))
+			for i, imp := range e.Imports {
+				imports[i] = strconv.Quote(imp)
+			}
+			lines = append(lines, fmt.Sprintf("  %s (%s) imports %s -> %s", e.From, strings.Join(g.nodes[e.From].Srcs, ", "), strings.Join(imports, ", "), e.To))
+		}
+		if mode == "error" {
+			failed = true
+			log.Print(Err("%s", strings.Join(lines, "\n")))
+		} else {
+			log.Print(Warn("%s", strings.Join(lines, "\n")))
+		}
+	}
+
+	if failed {
+		log.Fatal(Err("import cycles found, see above or set gazelle:js_import_cycles to warn"))
+	}
+}
+
+// addFileConsumers records the packages of the rules of f that are not
+// resolved by this extension, ie. hand-written or other language rules, as
+// consumers of the nodes they reference.
+func (g *dependencyGraph) addFileConsumers(f *rule.File) {
+	for _, r := range f.Rules {
+		if n, ok := g.nodes[graphLabel(label.New("", f.Pkg, r.Name()), "")]; ok && n.Kind != "" {
+			continue
+		}
+		for _, key := range r.AttrKeys() {
+			for _, value := range r.AttrStrings(key) {
+				lbl, err := label.Parse(value)
+				if err != nil {
+					continue
+				}
+				if n, ok := g.nodes[graphLabel(lbl.Abs("", f.Pkg), "")]; ok {
+					n.consumers[f.Pkg] = true
+				}
+			}
+		}
+	}
+}
+
+// setVisibility restricts the visibility of the rules with js_auto_visibility
+// to the packages depending on them, and the js_visibility labels. The
+// visibility is set on the rule of the build file too, since generated rules
+// were merged into existing ones already. Unless every package was visited,
+// the existing visibility is only extended, as consumers in the other
+// packages are unknown.
+func (lang *JS) setVisibility() {
+
+	if lang.coversRepo {
+		for _, f := range lang.files {
+			lang.graph.addFileConsumers(f)
+		}
+	}
+
+	for _, n := range lang.graph.nodes {
+		if n.rule == nil {
+			continue
+		}
+
+		rules := []*rule.Rule{n.rule}
+		if f, ok := lang.files[n.pkg]; ok {
+			for _, r := range f.Rules {
+				if r.Name() == n.rule.Name() && r != n.rule {
+					rules = append(rules, r)
+				}
+			}
+		}
+		for _, r := range rules {
+			if r.ShouldKeep() || keepsVisibility(r) {
+				continue
+			}
+
+			visibilitySet := make(map[string]bool)
+			for _, lbl := range n.visibility {
+				visibilitySet[lbl] = true
+			}
+			if !lang.coversRepo {
+				for _, lbl := range r.AttrStrings("visibility") {
+					if lbl != "//visibility:private" {
+						visibilitySet[lbl] = true
+					}
+				}
+			}
+			for pkg := range n.consumers {
+				if pkg != n.pkg && !isVisible(pkg, sortedKeys(visibilitySet)) {
+					visibilitySet[label.New("", pkg, "__pkg__").String()] = true
+				}
+			}
+			visibility := sortedKeys(visibilitySet)
+			if len(visibility) == 0 {
+				visibility = []string{"//visibility:private"}
+			}
+			r.SetAttr("visibility", visibility)
+		}
+	}
+}
+
+// isVisible reports whether the visibility labels already include pkg.
+func isVisible(pkg string, visibility []string) bool {
+	for _, v := range visibility {
+		lbl, err := label.Parse(v)
+		if err != nil {
+			continue
+		}
+		switch {
+		case v == "//visibility:public":
+			return true
+		case lbl.Name == "__pkg__" && lbl.Pkg == pkg:
+			return true
+		case lbl.Name == "__subpackages__" && (lbl.Pkg == "" || lbl.Pkg == pkg || strings.HasPrefix(pkg, lbl.Pkg+"/")):
+			return true
+		}
+	}
+	return false
+}
+
+// keepsVisibility reports whether the visibility of r has a "# keep" comment.
+func keepsVisibility(r *rule.Rule) bool {
+	comments := r.AttrComments("visibility")
+	return comments != nil && rule.ShouldKeep(&bzl.CommentBlock{Comments: *comments})
+}
+
+// AfterResolvingDeps writes the dependency graphs requested with
+// js_dependency_graph, sets the visibility of js_auto_visibility rules and
+// reports import cycles, once every rule is resolved.
+func (lang *JS) AfterResolvingDeps(ctx context.Context) {
+
+	lang.setVisibility()
+
+	// graphs are written along with build files, not with -mode=diff or print
+	files := make(map[string]bool)
+	for _, n := range lang.graph.nodes {
+		if n.export != "" && lang.writesFiles {
+			files[n.export] = true
+		}
+	}
+
+	for file := range files {
+		nodes := lang.graph.sortedNodes(file)
+
+		var data []byte
+		switch filepath.Ext(file) {
+		case ".dot", ".gv":
+			data = formatDOT(nodes)
+		default:
+			var err error
+			if data, err = formatJSON(nodes); err != nil {
+				log.Fatal(Err("failed to format dependency graph %s: %v", file, err))
+			}
+		}
+
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			log.Fatal(Err("failed to write dependency graph %s: %v", file, err))
+		}
+	}
+
+	lang.graph.reportCycles()
+}
+
+func formatJSON(nodes []*graphNode) ([]byte, error) {
+	graph := struct {
+		Nodes []*graphNode `json:"nodes"`
+		Edges []*graphEdge `json:"edges"`
+	}{
+		Nodes: nodes,
+		Edges: []*graphEdge{},
+	}
+	for _, n := range nodes {
+		graph.Edges = append(graph.Edges, n.sortedEdges()...)
+	}
+
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func formatDOT(nodes []*graphNode) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("digraph dependencies {\n")
+	for _, n := range nodes {
+		fmt.Fprintf(&buf, "  %q [kind=%q, srcs=%q];\n", n.Label, n.Kind, strings.Join(n.Srcs, ","))
+	}
+	for _, n := range nodes {
+		for _, e := range n.sortedEdges() {
+			style := "solid"
+			if e.Attr == "data" {
+				style = "dashed"
+			}
+			fmt.Fprintf(&buf, "  %q -> %q [label=%q, style=%s];\n", e.From, e.To, strings.Join(e.Imports, ","), style)
+		}
+	}
+	buf.WriteString("}\n")
+
+	return buf.Bytes()
+}
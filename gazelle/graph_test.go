@@ -0,0 +1,181 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func TestFindCycles(t *testing.T) {
+	type edge struct{ from, to, attr, imp string }
+	for _, tc := range []struct {
+		desc  string
+		edges []edge
+		want  [][]string
+	}{
+		{
+			desc: "no cycle",
+			edges: []edge{
+				{"//a:one", "//b:two", "deps", "../b/b"},
+				{"//b:two", "//c:three", "deps", "../c/c"},
+				{"//a:one", "//c:three", "deps", "../c/c"},
+			},
+			want: [][]string{},
+		},
+		{
+			desc: "cycle through packages",
+			edges: []edge{
+				{"//a:one", "//b:two", "deps", "../b/b"},
+				{"//b:two", "//c:three", "deps", "../c/c"},
+				{"//c:three", "//a:one", "deps", "../a/a"},
+				{"//c:three", "//:node_modules/lodash", "deps", "lodash"},
+			},
+			want: [][]string{{"//a:one", "//b:two", "//c:three"}},
+		},
+		{
+			desc: "shortest cycle of a component",
+			edges: []edge{
+				{"//a:one", "//a:b", "deps", "./b"},
+				{"//a:b", "//a:c", "deps", "./c"},
+				{"//a:c", "//a:one", "deps", "./a"},
+				{"//a:b", "//a:one", "deps", "./a"},
+			},
+			want: [][]string{{"//a:b", "//a:one"}},
+		},
+		{
+			desc: "data is not a cycle",
+			edges: []edge{
+				{"//a:one", "//b:two", "deps", "../b/b"},
+				{"//b:two", "//a:one", "data", "../a/a.json"},
+			},
+			want: [][]string{},
+		},
+		{
+			desc: "separate cycles",
+			edges: []edge{
+				{"//x:four", "//y:five", "deps", "../y/y"},
+				{"//y:five", "//x:four", "deps", "../x/x"},
+				{"//a:one", "//a:b", "deps", "./b"},
+				{"//a:b", "//a:one", "deps", "./a"},
+			},
+			want: [][]string{{"//a:b", "//a:one"}, {"//x:four", "//y:five"}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			g := newDependencyGraph()
+			g.edges = true
+			for _, e := range tc.edges {
+				from, err := label.Parse(e.from)
+				if err != nil {
+					t.Fatal(err)
+				}
+				g.addNode(from, rule.NewRule("ts_project", from.Name), NewJsConfig())
+				g.addEdge(from, e.to, e.attr, e.imp)
+			}
+
+			cycles := [][]string{}
+			for _, cycle := range g.findCycles() {
+				froms := []string{}
+				for i, e := range cycle {
+					froms = append(froms, e.From)
+					if next := cycle[(i+1)%len(cycle)]; e.To != next.From {
+						t.Errorf("edge %s -> %s is not followed by an edge from %s", e.From, e.To, e.To)
+					}
+				}
+				cycles = append(cycles, froms)
+			}
+
+			if !reflect.DeepEqual(cycles, tc.want) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", cycles, tc.want)
+			}
+		})
+	}
+}
+
+func TestCycleMode(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		configs map[string]func(*JsConfig)
+		want    string
+	}{
+		{
+			desc: "default",
+			want: "off",
+		},
+		{
+			desc: "warn",
+			configs: map[string]func(*JsConfig){
+				"//a:one": func(jsConfig *JsConfig) { jsConfig.ImportCycles = "warn" },
+				"//b:two": func(jsConfig *JsConfig) { jsConfig.ImportCycles = "warn" },
+			},
+			want: "warn",
+		},
+		{
+			desc: "quiet",
+			configs: map[string]func(*JsConfig){
+				"//a:one": func(jsConfig *JsConfig) { jsConfig.Quiet = true; jsConfig.ImportCycles = "warn" },
+				"//b:two": func(jsConfig *JsConfig) { jsConfig.Quiet = true; jsConfig.ImportCycles = "warn" },
+			},
+			want: "off",
+		},
+		{
+			desc: "quiet error",
+			configs: map[string]func(*JsConfig){
+				"//a:one": func(jsConfig *JsConfig) { jsConfig.Quiet = true; jsConfig.ImportCycles = "warn" },
+				"//b:two": func(jsConfig *JsConfig) { jsConfig.Quiet = true; jsConfig.ImportCycles = "error" },
+			},
+			want: "error",
+		},
+		{
+			desc: "one quiet package",
+			configs: map[string]func(*JsConfig){
+				"//a:one": func(jsConfig *JsConfig) { jsConfig.Quiet = true; jsConfig.ImportCycles = "warn" },
+				"//b:two": func(jsConfig *JsConfig) { jsConfig.ImportCycles = "warn" },
+			},
+			want: "warn",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			g := newDependencyGraph()
+			g.edges = true
+			for _, e := range [][2]string{{"//a:one", "//b:two"}, {"//b:two", "//a:one"}} {
+				from, err := label.Parse(e[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				jsConfig := NewJsConfig()
+				if configure, ok := tc.configs[e[0]]; ok {
+					configure(jsConfig)
+				}
+				g.addNode(from, rule.NewRule("ts_project", from.Name), jsConfig)
+				g.addEdge(from, e[1], "deps", "../x")
+			}
+
+			cycles := g.findCycles()
+			if len(cycles) != 1 {
+				t.Fatalf("expected one cycle, got %d", len(cycles))
+			}
+			if got := g.cycleMode(cycles[0]); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
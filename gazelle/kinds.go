@@ -53,6 +53,48 @@ func (*JS) Kinds() map[string]rule.KindInfo {
 				"data": true,
 			},
 		},
+		"vue_component": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs": true,
+				"tags": true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
+		"svelte_component": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs": true,
+				"tags": true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
+		"mdx_document": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs": true,
+				"tags": true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
 		"ts_definition": {
 			MatchAny: false,
 			NonEmptyAttrs: map[string]bool{
@@ -68,6 +110,63 @@ func (*JS) Kinds() map[string]rule.KindInfo {
 			},
 		},
 		"jest_test": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs":      true,
+				"snapshots": true,
+				"tags":      true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
+		"playwright_test": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs": true,
+				"tags": true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
+		"cypress_test": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs": true,
+				"tags": true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
+		"storybook_stories": {
+			MatchAny: false,
+			NonEmptyAttrs: map[string]bool{
+				"srcs": true,
+			},
+			MergeableAttrs: map[string]bool{
+				"srcs": true,
+				"tags": true,
+			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
+		},
+		"css_module": {
 			MatchAny: false,
 			NonEmptyAttrs: map[string]bool{
 				"srcs": true,
@@ -90,6 +189,10 @@ func (*JS) Kinds() map[string]rule.KindInfo {
 				"srcs": true,
 				"tags": true,
 			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
 		},
 		"web_assets": {
 			MatchAny: false,
@@ -100,6 +203,15 @@ func (*JS) Kinds() map[string]rule.KindInfo {
 				"srcs": true,
 				"tags": true,
 			},
+			ResolveAttrs: map[string]bool{
+				"deps": true,
+				"data": true,
+			},
 		},
+		// Code generation rules are never generated, they are only known so
+		// that their outputs are indexed. Wrapper macros can be added with
+		// # gazelle:alias_kind my_codegen genrule
+		"genrule":          {},
+		"ts_proto_library": {},
 	}
 }
@@ -18,6 +18,7 @@ package js
 
 import (
 	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/rule"
 )
 
 const languageName = "js"
@@ -30,8 +31,27 @@ func (*JS) Name() string {
 }
 
 type JS struct {
+	language.BaseLifecycleManager
+
+	// graph is the dependency graph of the resolved rules
+	graph *dependencyGraph
+	// files are the existing build files of each package, by package
+	files map[string]*rule.File
+	// coversRepo is set when every package of the repository is visited, so
+	// the consumers of each rule are known
+	coversRepo bool
+	// writesFiles is set when build files are updated in place, rather than
+	// printed or diffed, and the dependency graphs may be written too
+	writesFiles bool
+	// bundledTypes caches whether installed npm packages ship their own
+	// types, by package.json path
+	bundledTypes map[string]bool
 }
 
 func NewLanguage() language.Language {
-	return &JS{}
+	return &JS{
+		graph:        newDependencyGraph(),
+		files:        make(map[string]*rule.File),
+		bundledTypes: make(map[string]bool),
+	}
 }
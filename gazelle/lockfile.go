@@ -0,0 +1,609 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Lockfile lists the npm packages installed by a package manager.
+type Lockfile struct {
+	// Path is the repository relative path of the lockfile
+	Path string
+	// Importers maps each workspace project, by directory relative to the
+	// lockfile, to the versions of its direct dependencies by package name
+	Importers map[string]map[string]string
+	// Packages are the names of all installed packages, including transitive
+	// dependencies
+	Packages map[string]bool
+	// Workspaces are the directories of the workspace projects besides the
+	// root one, relative to the lockfile
+	Workspaces map[string]bool
+}
+
+func newLockfile(file string) *Lockfile {
+	return &Lockfile{
+		Path:       file,
+		Importers:  make(map[string]map[string]string),
+		Packages:   make(map[string]bool),
+		Workspaces: make(map[string]bool),
+	}
+}
+
+// importer returns the workspace project of the package.json in the
+// repository relative directory packageDir, or "" when it is not below the
+// lockfile.
+func (l *Lockfile) importer(packageDir string) string {
+	if packageDir == "" {
+		packageDir = "."
+	}
+	dir := path.Dir(l.Path)
+	if dir == "." {
+		return path.Clean(packageDir)
+	}
+	if packageDir == dir {
+		return "."
+	}
+	if rel, ok := strings.CutPrefix(packageDir, dir+"/"); ok {
+		return rel
+	}
+	return ""
+}
+
+// isWorkspace reports whether the repository relative directory rel is one of
+// the workspace projects.
+func (l *Lockfile) isWorkspace(rel string) bool {
+	importer := l.importer(rel)
+	return importer != "" && l.Workspaces[importer]
+}
+
+// isInstalled reports whether the package name is a direct dependency of the
+// workspace project importer.
+func (l *Lockfile) isInstalled(importer string, name string) bool {
+	_, ok := l.Importers[importer][name]
+	return ok
+}
+
+// conflicts returns the packages that workspace projects depend on with
+// different versions, formatted as "version (importer, ...)". Links to other
+// workspace projects are not versions.
+func (l *Lockfile) conflicts() map[string][]string {
+
+	versions := make(map[string]map[string][]string)
+	for importer, deps := range l.Importers {
+		for name, version := range deps {
+			if strings.HasPrefix(version, "link:") || strings.HasPrefix(version, "workspace:") || version == "" {
+				continue
+			}
+			// peer dependencies do not change the version, ie. "18.2.0(react@18.2.0)"
+			if i := strings.Index(version, "("); i > 0 {
+				version = version[:i]
+			}
+			if _, ok := versions[name]; !ok {
+				versions[name] = make(map[string][]string)
+			}
+			versions[name][version] = append(versions[name][version], importer)
+		}
+	}
+
+	conflicts := make(map[string][]string)
+	for name, importers := range versions {
+		if len(importers) < 2 {
+			continue
+		}
+		for version, projects := range importers {
+			sort.Strings(projects)
+			conflicts[name] = append(conflicts[name], fmt.Sprintf("%s (%s)", version, strings.Join(projects, ", ")))
+		}
+		sort.Strings(conflicts[name])
+	}
+	return conflicts
+}
+
+// readPnpmLockfile reads the importers and packages of a pnpm-lock.yaml. Only
+// the block structure pnpm writes is supported, which avoids a YAML parser.
+// Lockfiles of a single project list their dependencies at the top level, and
+// are read as the "." importer.
+func readPnpmLockfile(file string, data []byte) (*Lockfile, error) {
+
+	lockfile := newLockfile(file)
+
+	section := ""
+	importer := ""
+	depType := ""
+	name := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		key, value, ok := splitYAMLKey(trimmed)
+		if !ok {
+			if indent == 0 {
+				return nil, fmt.Errorf("line %d: expected a key, got %q", lineNumber, trimmed)
+			}
+			continue
+		}
+
+		if indent == 0 {
+			section = key
+			importer = ""
+			depType = ""
+			name = ""
+			if isDependencyType(section) {
+				importer = "."
+				depType = section
+			}
+			continue
+		}
+
+		switch section {
+
+		case "importers":
+			switch indent {
+			case 2:
+				importer = path.Clean(key)
+				depType = ""
+				if _, ok := lockfile.Importers[importer]; !ok {
+					lockfile.Importers[importer] = make(map[string]string)
+				}
+			case 4:
+				depType = key
+			case 6:
+				name = addLockfileDependency(lockfile, importer, depType, key, value)
+			case 8:
+				if key == "version" && name != "" {
+					lockfile.Importers[importer][name] = value
+				}
+			}
+
+		case "dependencies", "devDependencies", "optionalDependencies":
+			switch indent {
+			case 2:
+				name = addLockfileDependency(lockfile, importer, depType, key, value)
+			case 4:
+				if key == "version" && name != "" {
+					lockfile.Importers[importer][name] = value
+				}
+			}
+
+		case "packages", "snapshots":
+			if indent == 2 {
+				if pkg := pnpmPackageName(key); pkg != "" {
+					lockfile.Packages[pkg] = true
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// direct dependencies are installed packages as well
+	for importer, deps := range lockfile.Importers {
+		if importer != "." {
+			lockfile.Workspaces[importer] = true
+		}
+		for name, version := range deps {
+			if !strings.HasPrefix(version, "link:") {
+				lockfile.Packages[name] = true
+			}
+		}
+	}
+
+	return lockfile, nil
+}
+
+// addLockfileDependency records a direct dependency of importer, with the
+// version given inline by older lockfiles. It returns the package name, or ""
+// when depType does not hold dependencies.
+func addLockfileDependency(lockfile *Lockfile, importer string, depType string, name string, version string) string {
+	if !isDependencyType(depType) {
+		return ""
+	}
+	if _, ok := lockfile.Importers[importer]; !ok {
+		lockfile.Importers[importer] = make(map[string]string)
+	}
+	lockfile.Importers[importer][name] = version
+	return name
+}
+
+func isDependencyType(key string) bool {
+	return key == "dependencies" || key == "devDependencies" || key == "optionalDependencies"
+}
+
+// pnpmPackageName returns the name of a package key of the packages and
+// snapshots sections, ie. "/@babel/core@7.23.0(supports-color@8.1.1)" or
+// "/@babel/core/7.23.0_supports-color@8.1.1" in lockfiles before v6.
+func pnpmPackageName(key string) string {
+	key = strings.TrimPrefix(key, "/")
+	scope := ""
+	if strings.HasPrefix(key, "@") {
+		i := strings.Index(key, "/")
+		if i < 0 {
+			return ""
+		}
+		scope = key[:i+1]
+		key = key[i+1:]
+	}
+	end := strings.IndexAny(key, "@/")
+	if end <= 0 {
+		return ""
+	}
+	return scope + key[:end]
+}
+
+// splitYAMLKey splits a "key: value" line, unquoting both. Flow mappings like
+// "{integrity: ...}" are returned as is.
+func splitYAMLKey(line string) (string, string, bool) {
+	if strings.HasPrefix(line, "- ") {
+		return "", "", false
+	}
+	key := ""
+	rest := ""
+	if strings.HasPrefix(line, "'") || strings.HasPrefix(line, "\"") {
+		end := strings.Index(line[1:], line[:1])
+		if end < 0 {
+			return "", "", false
+		}
+		key = line[1 : end+1]
+		rest = line[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(line, ":")
+		for i >= 0 && i+1 < len(line) && line[i+1] != ' ' {
+			// colons inside keys, ie. "link:../ui"
+			next := strings.Index(line[i+1:], ":")
+			if next < 0 {
+				i = -1
+				break
+			}
+			i += next + 1
+		}
+		if i < 0 {
+			return "", "", false
+		}
+		key = line[:i]
+		rest = line[i+1:]
+	}
+	return key, unquoteYAML(strings.TrimSpace(rest)), true
+}
+
+func unquoteYAML(value string) string {
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// readLockfile reads the lockfile at the repository relative path file, in the
+// format of the package manager its name belongs to.
+func readLockfile(repoRoot string, file string) (*Lockfile, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, file))
+	if err != nil {
+		return nil, err
+	}
+	switch path.Base(file) {
+	case "pnpm-lock.yaml":
+		return readPnpmLockfile(file, data)
+	case "package-lock.json":
+		return readNpmLockfile(file, data)
+	case "yarn.lock":
+		workspaces, err := readWorkspaces(repoRoot, path.Dir(file))
+		if err != nil {
+			return nil, err
+		}
+		return readYarnLockfile(file, data, workspaces)
+	default:
+		return nil, fmt.Errorf("unknown lockfile %s, expected pnpm-lock.yaml, yarn.lock or package-lock.json", file)
+	}
+}
+
+// readNpmLockfile reads the packages of a package-lock.json. Workspace projects
+// are the packages outside of node_modules, and their dependencies are
+// installed in their own node_modules or hoisted to the root one. Lockfiles
+// before v2 only list the dependencies of the root project, hoisted ones
+// included.
+func readNpmLockfile(file string, data []byte) (*Lockfile, error) {
+
+	type npmPackage struct {
+		Version              string            `json:"version"`
+		Resolved             string            `json:"resolved"`
+		Link                 bool              `json:"link"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	manifest := struct {
+		Packages     map[string]npmPackage `json:"packages"`
+		Dependencies map[string]npmPackage `json:"dependencies"`
+	}{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	lockfile := newLockfile(file)
+
+	if len(manifest.Packages) == 0 {
+		lockfile.Importers["."] = make(map[string]string)
+		for name, pkg := range manifest.Dependencies {
+			lockfile.Importers["."][name] = pkg.Version
+			lockfile.Packages[name] = true
+		}
+		return lockfile, nil
+	}
+
+	for key, pkg := range manifest.Packages {
+
+		if i := strings.LastIndex(key, "node_modules/"); i >= 0 {
+			if !pkg.Link {
+				lockfile.Packages[key[i+len("node_modules/"):]] = true
+			}
+			continue
+		}
+
+		importer := key
+		if importer == "" {
+			importer = "."
+		} else {
+			lockfile.Workspaces[importer] = true
+		}
+		lockfile.Importers[importer] = make(map[string]string)
+		for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies} {
+			for name := range deps {
+				for _, installed := range []string{path.Join(key, "node_modules", name), path.Join("node_modules", name)} {
+					if dep, ok := manifest.Packages[installed]; ok {
+						if dep.Link {
+							lockfile.Importers[importer][name] = "link:" + dep.Resolved
+						} else {
+							lockfile.Importers[importer][name] = dep.Version
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return lockfile, nil
+}
+
+// readYarnLockfile reads the packages of a yarn.lock, in the format of yarn
+// v1 or of yarn berry. The lockfile maps descriptors like "lodash@^4.17" to
+// versions, the dependencies of each workspace project come from its
+// package.json.
+func readYarnLockfile(file string, data []byte, workspaces map[string]packageManifest) (*Lockfile, error) {
+
+	lockfile := newLockfile(file)
+
+	versions := make(map[string]string)
+	descriptors := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") {
+			// "lodash@^4.17.0", "lodash@^4.17.21": in yarn v1, or
+			// "lodash@npm:^4.17.0, lodash@npm:^4.17.21": in yarn berry
+			descriptors = descriptors[:0]
+			for _, descriptor := range strings.Split(strings.TrimSuffix(trimmed, ":"), ", ") {
+				descriptor = strings.Trim(descriptor, "\"'")
+				if descriptor == "__metadata" {
+					continue
+				}
+				descriptors = append(descriptors, descriptor)
+				name, reference := yarnDescriptorName(descriptor)
+				if name != "" && !strings.HasPrefix(reference, "workspace:") {
+					lockfile.Packages[name] = true
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "   ") {
+			continue
+		}
+		if version, ok := strings.CutPrefix(trimmed, "version"); ok {
+			version = unquoteYAML(strings.TrimSpace(strings.TrimPrefix(version, ":")))
+			for _, descriptor := range descriptors {
+				versions[descriptor] = version
+			}
+		}
+		// resolution: "@acme/ui@workspace:packages/ui" in yarn berry
+		if resolution, ok := strings.CutPrefix(trimmed, "resolution:"); ok {
+			_, reference := yarnDescriptorName(unquoteYAML(strings.TrimSpace(resolution)))
+			if dir, ok := strings.CutPrefix(reference, "workspace:"); ok && path.Clean(dir) != "." {
+				lockfile.Workspaces[path.Clean(dir)] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// workspace projects link each other by name
+	names := make(map[string]string)
+	for dir, manifest := range workspaces {
+		if dir != "." {
+			lockfile.Workspaces[dir] = true
+		}
+		if manifest.Name != "" {
+			names[manifest.Name] = dir
+		}
+	}
+
+	for dir, manifest := range workspaces {
+		lockfile.Importers[dir] = make(map[string]string)
+		for _, deps := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.OptionalDependencies} {
+			for name, version := range deps {
+				if linked, ok := names[name]; ok {
+					lockfile.Importers[dir][name] = "link:" + linked
+				} else if installed, ok := versions[name+"@"+version]; ok {
+					lockfile.Importers[dir][name] = installed
+				} else if installed, ok := versions[name+"@npm:"+version]; ok {
+					lockfile.Importers[dir][name] = installed
+				}
+			}
+		}
+	}
+
+	return lockfile, nil
+}
+
+// yarnDescriptorName splits a descriptor like "@babel/core@npm:^7.23.0" into
+// the package name and its range or reference.
+func yarnDescriptorName(descriptor string) (string, string) {
+	i := strings.Index(descriptor[min(1, len(descriptor)):], "@")
+	if i < 0 {
+		return "", ""
+	}
+	return descriptor[:i+1], descriptor[i+2:]
+}
+
+// packageManifest is the part of a package.json describing its workspace and
+// dependencies.
+type packageManifest struct {
+	Name                 string            `json:"name"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+}
+
+func readPackageManifest(file string) (packageManifest, error) {
+	manifest := packageManifest{}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	return manifest, nil
+}
+
+// readWorkspaces returns the package.json of the workspace projects listed
+// by the "workspaces" of the package.json in the repository relative
+// directory dir, by directory relative to dir. The root project is ".".
+// Workspaces are glob patterns, where a trailing "/**" matches every
+// directory below a prefix and a leading "!" excludes directories.
+func readWorkspaces(repoRoot string, dir string) (map[string]packageManifest, error) {
+
+	root := filepath.Join(repoRoot, dir)
+	rootManifest, err := readPackageManifest(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	workspaces := map[string]packageManifest{
+		".": rootManifest,
+	}
+
+	// "workspaces": [...] or "workspaces": {"packages": [...]}
+	patterns := []string{}
+	if len(rootManifest.Workspaces) > 0 {
+		if err := json.Unmarshal(rootManifest.Workspaces, &patterns); err != nil {
+			nested := struct {
+				Packages []string `json:"packages"`
+			}{}
+			if err := json.Unmarshal(rootManifest.Workspaces, &nested); err != nil {
+				return nil, fmt.Errorf("failed to parse workspaces of %s: %v", path.Join(dir, "package.json"), err)
+			}
+			patterns = nested.Packages
+		}
+	}
+
+	includes := []string{}
+	excludes := []string{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(path.Clean(pattern), "./")
+		if exclude, ok := strings.CutPrefix(pattern, "!"); ok {
+			excludes = append(excludes, exclude)
+		} else {
+			includes = append(includes, pattern)
+		}
+	}
+
+	candidates := make(map[string]bool)
+	for _, pattern := range includes {
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			filepath.WalkDir(filepath.Join(root, prefix), func(p string, d os.DirEntry, err error) error {
+				if err != nil || !d.IsDir() {
+					return nil
+				}
+				if d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if rel, err := filepath.Rel(root, p); err == nil {
+					candidates[filepath.ToSlash(rel)] = true
+				}
+				return nil
+			})
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace %s: %v", pattern, err)
+		}
+		for _, match := range matches {
+			if rel, err := filepath.Rel(root, match); err == nil {
+				candidates[filepath.ToSlash(rel)] = true
+			}
+		}
+	}
+
+	for candidate := range candidates {
+		excluded := candidate == "."
+		for _, pattern := range excludes {
+			excluded = excluded || matchImportPattern(pattern, candidate)
+		}
+		if excluded {
+			continue
+		}
+		manifest, err := readPackageManifest(filepath.Join(root, candidate, "package.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		workspaces[candidate] = manifest
+	}
+
+	return workspaces, nil
+}
@@ -0,0 +1,342 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadPnpmLockfile(t *testing.T) {
+	for _, tc := range []struct {
+		desc, lockfile string
+		importers      map[string]map[string]string
+		packages       []string
+	}{
+		{
+			desc: "workspace v9",
+			lockfile: `
+lockfileVersion: '9.0'
+
+settings:
+  autoInstallPeers: true
+
+importers:
+
+  .:
+    devDependencies:
+      '@types/lodash':
+        specifier: ^4.17
+        version: 4.17.0
+
+  packages/app:
+    dependencies:
+      '@acme/ui':
+        specifier: workspace:*
+        version: link:../ui
+      react:
+        specifier: ^18
+        version: 18.2.0
+
+packages:
+
+  '@types/lodash@4.17.0':
+    resolution: {integrity: sha512-abc}
+
+  js-tokens@4.0.0:
+    resolution: {integrity: sha512-def}
+
+snapshots:
+
+  '@types/lodash@4.17.0': {}
+
+  react@18.2.0:
+    dependencies:
+      loose-envify: 1.4.0
+`,
+			importers: map[string]map[string]string{
+				".":            {"@types/lodash": "4.17.0"},
+				"packages/app": {"@acme/ui": "link:../ui", "react": "18.2.0"},
+			},
+			packages: []string{"@types/lodash", "js-tokens", "react"},
+		}, {
+			desc: "single project v6",
+			lockfile: `
+lockfileVersion: '6.0'
+
+dependencies:
+  '@babel/core':
+    specifier: ^7.23.0
+    version: 7.23.0(supports-color@8.1.1)
+
+packages:
+
+  /@babel/core@7.23.0(supports-color@8.1.1):
+    resolution: {integrity: sha512-abc}
+    dependencies:
+      '@babel/parser': 7.23.0
+`,
+			importers: map[string]map[string]string{
+				".": {"@babel/core": "7.23.0(supports-color@8.1.1)"},
+			},
+			packages: []string{"@babel/core"},
+		}, {
+			desc: "workspace v5",
+			lockfile: `
+lockfileVersion: 5.4
+
+importers:
+
+  .:
+    specifiers:
+      lodash: ^4.17
+    dependencies:
+      lodash: 4.17.21
+
+packages:
+
+  /lodash/4.17.21:
+    resolution: {integrity: sha512-abc}
+
+  /@emotion/react/11.11.0_react@18.2.0:
+    resolution: {integrity: sha512-def}
+`,
+			importers: map[string]map[string]string{
+				".": {"lodash": "4.17.21"},
+			},
+			packages: []string{"@emotion/react", "lodash"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			lockfile, err := readPnpmLockfile("pnpm-lock.yaml", []byte(tc.lockfile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(lockfile.Importers, tc.importers) {
+				t.Errorf("expected importers %v, got %v", tc.importers, lockfile.Importers)
+			}
+			for _, pkg := range tc.packages {
+				if !lockfile.Packages[pkg] {
+					t.Errorf("expected package %s in %v", pkg, lockfile.Packages)
+				}
+			}
+			if len(lockfile.Packages) != len(tc.packages) {
+				t.Errorf("expected packages %v, got %v", tc.packages, lockfile.Packages)
+			}
+		})
+	}
+}
+
+func TestLockfileConflicts(t *testing.T) {
+	lockfile := newLockfile("pnpm-lock.yaml")
+	lockfile.Importers = map[string]map[string]string{
+		"apps/web":      {"react": "18.2.0(react-dom@18.2.0)", "@acme/ui": "link:../../packages/ui"},
+		"apps/admin":    {"react": "17.0.2", "@acme/ui": "link:../../packages/ui"},
+		"packages/ui":   {"react": "18.2.0"},
+		"packages/util": {"lodash": "4.17.21"},
+	}
+	want := map[string][]string{
+		"react": {"17.0.2 (apps/admin)", "18.2.0 (apps/web, packages/ui)"},
+	}
+	if got := lockfile.conflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLockfileImporter(t *testing.T) {
+	for _, tc := range []struct {
+		desc, lockfile, packageDir, want string
+	}{
+		{
+			desc:       "root",
+			lockfile:   "pnpm-lock.yaml",
+			packageDir: "",
+			want:       ".",
+		}, {
+			desc:       "workspace package",
+			lockfile:   "pnpm-lock.yaml",
+			packageDir: "packages/app",
+			want:       "packages/app",
+		}, {
+			desc:       "nested lockfile",
+			lockfile:   "web/pnpm-lock.yaml",
+			packageDir: "web/packages/app",
+			want:       "packages/app",
+		}, {
+			desc:       "nested lockfile root",
+			lockfile:   "web/pnpm-lock.yaml",
+			packageDir: "web",
+			want:       ".",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := newLockfile(tc.lockfile).importer(tc.packageDir); got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReadNpmLockfile(t *testing.T) {
+	lockfile, err := readNpmLockfile("package-lock.json", []byte(`{
+  "name": "root",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "root",
+      "workspaces": ["packages/*"],
+      "devDependencies": {"typescript": "^5.2.0"}
+    },
+    "packages/app": {
+      "name": "app",
+      "dependencies": {"@acme/ui": "*", "react": "^17.0.0", "zod": "^3"}
+    },
+    "packages/ui": {
+      "name": "@acme/ui",
+      "dependencies": {"react": "^18.2.0"}
+    },
+    "node_modules/@acme/ui": {"resolved": "packages/ui", "link": true},
+    "node_modules/app": {"resolved": "packages/app", "link": true},
+    "node_modules/js-tokens": {"version": "4.0.0"},
+    "node_modules/react": {"version": "18.2.0"},
+    "node_modules/typescript": {"version": "5.2.2", "dev": true},
+    "packages/app/node_modules/react": {"version": "17.0.2"}
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	importers := map[string]map[string]string{
+		".":            {"typescript": "5.2.2"},
+		"packages/app": {"@acme/ui": "link:packages/ui", "react": "17.0.2"},
+		"packages/ui":  {"react": "18.2.0"},
+	}
+	if !reflect.DeepEqual(lockfile.Importers, importers) {
+		t.Errorf("expected importers %v, got %v", importers, lockfile.Importers)
+	}
+	packages := map[string]bool{"js-tokens": true, "react": true, "typescript": true}
+	if !reflect.DeepEqual(lockfile.Packages, packages) {
+		t.Errorf("expected packages %v, got %v", packages, lockfile.Packages)
+	}
+	workspaces := map[string]bool{"packages/app": true, "packages/ui": true}
+	if !reflect.DeepEqual(lockfile.Workspaces, workspaces) {
+		t.Errorf("expected workspaces %v, got %v", workspaces, lockfile.Workspaces)
+	}
+}
+
+func TestReadYarnLockfile(t *testing.T) {
+	workspaces := map[string]packageManifest{
+		".": {
+			Name:            "root",
+			DevDependencies: map[string]string{"typescript": "^5.2.0"},
+		},
+		"packages/app": {
+			Name:         "app",
+			Dependencies: map[string]string{"@acme/ui": "workspace:*", "@babel/core": "^7.23.0", "zod": "^3"},
+		},
+		"packages/ui": {
+			Name: "@acme/ui",
+		},
+	}
+	lockfile, err := readYarnLockfile("yarn.lock", []byte(`# This file is generated by running "yarn install" inside your project.
+
+__metadata:
+  version: 6
+  cacheKey: 8
+
+"@acme/ui@workspace:*, @acme/ui@workspace:packages/ui":
+  version: 0.0.0-use.local
+  resolution: "@acme/ui@workspace:packages/ui"
+  languageName: unknown
+  linkType: soft
+
+"@babel/core@npm:^7.23.0":
+  version: 7.23.0
+  resolution: "@babel/core@npm:7.23.0"
+  dependencies:
+    "@babel/parser": ^7.23.0
+  languageName: node
+  linkType: hard
+
+"@babel/parser@npm:^7.23.0":
+  version: 7.23.0
+  resolution: "@babel/parser@npm:7.23.0"
+
+"typescript@npm:^5.2.0":
+  version: 5.2.2
+  resolution: "typescript@npm:5.2.2"
+`), workspaces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importers := map[string]map[string]string{
+		".":            {"typescript": "5.2.2"},
+		"packages/app": {"@acme/ui": "link:packages/ui", "@babel/core": "7.23.0"},
+		"packages/ui":  {},
+	}
+	if !reflect.DeepEqual(lockfile.Importers, importers) {
+		t.Errorf("expected importers %v, got %v", importers, lockfile.Importers)
+	}
+	packages := map[string]bool{"@babel/core": true, "@babel/parser": true, "typescript": true}
+	if !reflect.DeepEqual(lockfile.Packages, packages) {
+		t.Errorf("expected packages %v, got %v", packages, lockfile.Packages)
+	}
+	if want := map[string]bool{"packages/app": true, "packages/ui": true}; !reflect.DeepEqual(lockfile.Workspaces, want) {
+		t.Errorf("expected workspaces %v, got %v", want, lockfile.Workspaces)
+	}
+	if !lockfile.isWorkspace("packages/ui") || lockfile.isWorkspace("") {
+		t.Errorf("expected packages/ui to be a workspace and the root not to be one")
+	}
+}
+
+func TestReadWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	for file, manifest := range map[string]string{
+		"web/package.json":                            `{"name": "web", "workspaces": {"packages": ["apps/*", "libs/**", "!libs/legacy"]}}`,
+		"web/apps/admin/package.json":                 `{"name": "admin"}`,
+		"web/apps/docs/README.md":                     `# Not a workspace`,
+		"web/libs/ui/package.json":                    `{"name": "@acme/ui"}`,
+		"web/libs/ui/node_modules/react/package.json": `{"name": "react"}`,
+		"web/libs/legacy/package.json":                `{"name": "legacy"}`,
+	} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, file), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workspaces, err := readWorkspaces(root, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	for dir, manifest := range workspaces {
+		names[dir] = manifest.Name
+	}
+	want := map[string]string{
+		".":          "web",
+		"apps/admin": "admin",
+		"libs/ui":    "@acme/ui",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
@@ -82,12 +82,16 @@ func removeComments(data []byte) []byte {
 		// Check for multi-line comment
 		if i+1 < len(data) && data[i] == '/' && data[i+1] == '*' {
 			i += 2
-			// Skip until we find */
+			// Skip until we find */, keeping line breaks so that imports stay
+			// on the line of their gazelle comments
 			for i+1 < len(data) {
 				if data[i] == '*' && data[i+1] == '/' {
 					i += 2
 					break
 				}
+				if data[i] == '\n' {
+					result.WriteByte('\n')
+				}
 				i++
 			}
 			result.WriteByte(' ')
@@ -101,17 +105,193 @@ func removeComments(data []byte) []byte {
 	return []byte(result.String())
 }
 
-func ParseJS(data []byte) ([]string, int, error) {
+// ParseResult is what a parsed source file imports
+type ParseResult struct {
+	// Imports are the modules imported by the file, sorted
+	Imports []string
+	// Globs are the sets of modules imported through bundler globs
+	Globs []GlobImport
+	// Overrides are the labels of imports with a gazelle:resolve comment
+	Overrides map[string]string
+	// JestTestCount is the number of jest tests in the file
+	JestTestCount int
+}
+
+// ParseJS extracts the imports and glob imports of a JavaScript or TypeScript
+// file, and the number of jest tests it holds. An import whose line ends with a
+// gazelle:ignore comment is dropped, and one whose line ends with a
+// gazelle:resolve comment is returned apart, with the label it resolves to,
+// eg.
+//
+//	const optional = require("optional-dep") // gazelle:ignore
+//	import { api } from "server-only-module" // gazelle:resolve //server:api
+func ParseJS(data []byte) (*ParseResult, error) {
+	// Read the comments of import lines before they are removed
+	comments := parseImportComments(data)
+
 	// Remove comments in a single efficient pass
 	cleanedData := removeComments(data)
 
-	imports, jestTestCount, err := parseCodeBlock(cleanedData)
+	result, err := parseCodeBlock(cleanedData, comments)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	result.Globs, err = parseGlobImports(cleanedData)
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Strings(imports)
-	return imports, jestTestCount, nil
+	return result, nil
+}
+
+// importComment is a gazelle:ignore or gazelle:resolve comment ending a line
+type importComment struct {
+	directive string
+	label     string
+}
+
+var importCommentPattern = regexp.MustCompile(`//[ \t]*gazelle:(ignore|resolve)\b[ \t]*(\S*)`)
+
+// parseImportComments returns the gazelle:ignore and gazelle:resolve comments
+// of data, by line number.
+func parseImportComments(data []byte) map[int]importComment {
+	comments := make(map[int]importComment)
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if match := importCommentPattern.FindSubmatch(line); match != nil {
+			comments[i] = importComment{
+				directive: string(match[1]),
+				label:     string(match[2]),
+			}
+		}
+	}
+
+	return comments
+}
+
+var (
+	sfcScriptPattern = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+	sfcStylePattern  = regexp.MustCompile(`(?is)<style\b([^>]*)>`)
+	sfcSrcPattern    = regexp.MustCompile(`(?is)\bsrc\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	sfcIndentPattern = regexp.MustCompile(`(?m)^[ \t]+`)
+)
+
+// ParseSFC extracts the imports of a Vue or Svelte single-file component.
+// Each <script> block, including <script setup lang="ts"> and
+// <script context="module">, is parsed with ParseJS, and the src attribute of
+// <script> and <style> blocks is an import as well.
+func ParseSFC(data []byte) (*ParseResult, error) {
+	result := &ParseResult{
+		Imports:   make([]string, 0),
+		Globs:     make([]GlobImport, 0),
+		Overrides: make(map[string]string),
+	}
+
+	for _, match := range sfcScriptPattern.FindAllSubmatch(data, -1) {
+		if srcMatch := sfcSrcPattern.FindSubmatch(match[1]); srcMatch != nil {
+			result.Imports = append(result.Imports, firstGroup(srcMatch))
+		}
+		// script blocks are commonly indented, while import statements are
+		// only matched at the start of a line
+		script := sfcIndentPattern.ReplaceAll(match[2], nil)
+		scriptResult, err := ParseJS(script)
+		if err != nil {
+			return nil, err
+		}
+		result.Imports = append(result.Imports, scriptResult.Imports...)
+		result.Globs = append(result.Globs, scriptResult.Globs...)
+		for imp, lbl := range scriptResult.Overrides {
+			result.Overrides[imp] = lbl
+		}
+	}
+
+	for _, match := range sfcStylePattern.FindAllSubmatch(data, -1) {
+		if srcMatch := sfcSrcPattern.FindSubmatch(match[1]); srcMatch != nil {
+			result.Imports = append(result.Imports, firstGroup(srcMatch))
+		}
+	}
+
+	sort.Strings(result.Imports)
+	return result, nil
+}
+
+var (
+	mdxFencePattern = regexp.MustCompile("^\\s*(```|~~~)")
+	mdxESMPattern   = regexp.MustCompile(`^(import|export)\b`)
+)
+
+// ParseMDX extracts the imports of an MDX document. Only ESM blocks are
+// parsed, which are paragraphs starting with import or export at the top
+// level of the document, outside of fenced code blocks.
+func ParseMDX(data []byte) (*ParseResult, error) {
+	var esm bytes.Buffer
+
+	inFence := ""
+	inESM := false
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if match := mdxFencePattern.FindSubmatch(line); match != nil {
+			if inFence == "" {
+				inFence = string(match[1])
+			} else if inFence == string(match[1]) {
+				inFence = ""
+			}
+			inESM = false
+			continue
+		}
+		if inFence != "" {
+			continue
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			inESM = false
+			continue
+		}
+		if !inESM && mdxESMPattern.Match(line) {
+			inESM = true
+		}
+		if inESM {
+			esm.Write(line)
+			esm.WriteByte('\n')
+		}
+	}
+
+	result, err := ParseJS(esm.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// jest tests are not run from documents
+	result.JestTestCount = 0
+	return result, nil
+}
+
+var (
+	tripleSlashPattern    = regexp.MustCompile(`(?m)^[ \t]*///[ \t]*<reference\b([^>\n]*)/?>`)
+	referencePathPattern  = regexp.MustCompile(`\bpath\s*=\s*(?:"([^"\n]*)"|'([^'\n]*)')`)
+	referenceTypesPattern = regexp.MustCompile(`\btypes\s*=\s*(?:"([^"\n]*)"|'([^'\n]*)')`)
+)
+
+// ParseReferences extracts TypeScript triple-slash reference directives,
+// which are comments and therefore ignored by ParseJS. It returns the
+// declaration files of /// <reference path="..." />, as imports relative to the
+// file, and the type packages of /// <reference types="..." />.
+func ParseReferences(data []byte) ([]string, []string) {
+	paths := make(map[string]bool)
+	types := make(map[string]bool)
+
+	for _, match := range tripleSlashPattern.FindAllSubmatch(data, -1) {
+		if pathMatch := referencePathPattern.FindSubmatch(match[1]); pathMatch != nil {
+			ref := firstGroup(pathMatch)
+			if !strings.HasPrefix(ref, ".") && !strings.HasPrefix(ref, "/") {
+				// reference paths are always relative to the referencing file
+				ref = "./" + ref
+			}
+			paths[ref] = true
+		}
+		if typesMatch := referenceTypesPattern.FindSubmatch(match[1]); typesMatch != nil {
+			types[firstGroup(typesMatch)] = true
+		}
+	}
+
+	return sortedKeys(paths), sortedKeys(types)
 }
 
 const (
@@ -121,24 +301,188 @@ const (
 	JEST_MOCK      = 4
 	DYNAMIC_IMPORT = 5
 	DECLARE_MODULE = 6
+	NEW_URL        = 7
 )
 
 var jsImportPattern = compileJsImportPattern()
 
 func compileJsImportPattern() *regexp.Regexp {
-	stringLiteralPattern := `'(?:[^\n]+|")*'|"(?:[^\n]+|')*"`
+	// specifiers are often followed by other code on the same line (mock
+	// factories, type arguments, other imports), so they must stop at the
+	// closing quote
+	stringLiteralPattern := `'[^'\n]*'|"[^"\n]*"`
 	importPattern := `^import\s(?:(?:.|\n)+?from )??(?P<import>` + stringLiteralPattern + `)`
 	requirePattern := `^\s*?(?:const .+ = )?require\((?P<require>` + stringLiteralPattern + `)\)`
 	exportPattern := `^export\s(?:(?:.|\n)+?from )??(?P<export>` + stringLiteralPattern + `)`
-	jestMockPattern := `^\s*?(?:const .+ = )?jest.mock\((?P<jestMock>` + stringLiteralPattern + `),`
+	jestMockPattern := `^.*?\b(?:jest|vi)\.(?:mock|doMock|requireActual|requireMock|unstable_mockModule|importActual|importMock)(?:<[^>\n]*>)?\(\s*(?P<jestMock>` + stringLiteralPattern + `)\s*[,)]`
 	dynamicImportPattern := `^.*?import\((?P<dynamicImport>` + stringLiteralPattern + `)\)`
 	declareModulePattern := `^declare\s+module\s+(?P<declareModule>` + stringLiteralPattern + `)`
-	return regexp.MustCompile(`(?m)` + strings.Join([]string{importPattern, requirePattern, exportPattern, jestMockPattern, dynamicImportPattern, declareModulePattern}, "|"))
+	newURLPattern := `\bnew\s+URL\(\s*(?P<newURL>` + stringLiteralPattern + `)\s*,\s*import\.meta\.url\s*\)`
+	return regexp.MustCompile(`(?m)` + strings.Join([]string{importPattern, requirePattern, exportPattern, jestMockPattern, dynamicImportPattern, declareModulePattern, newURLPattern}, "|"))
+}
+
+// GlobImport is a set of modules imported through a bundler glob, like
+// import.meta.glob("./pages/*.tsx") or require.context("./icons", true, /\.svg$/)
+type GlobImport struct {
+	// Dir is the directory the glob is relative to
+	Dir string
+	// Recursive is true when files in subdirectories of Dir can match
+	Recursive bool
+	// Pattern matches the paths of imported files relative to Dir, with a "./" prefix
+	Pattern *regexp.Regexp
+	// Excludes are the negative patterns of the glob, without their "!",
+	// relative to the importing file with a "./" or "../" prefix, or to the
+	// project root with a "/" prefix
+	Excludes []string
+	// UnsupportedFilter is a require.context filter that Go regular
+	// expressions cannot compile, eg. with a lookahead. Pattern then matches
+	// every file.
+	UnsupportedFilter string
+}
+
+var (
+	importMetaGlobPattern = regexp.MustCompile(`import\.meta\.glob(?:Eager)?\s*(?:<[^>\n]*>)?\(\s*('[^'\n]*'|"[^"\n]*"|\[[^\]]*\])`)
+	requireContextPattern = regexp.MustCompile(`require\.context\(\s*('[^'\n]*'|"[^"\n]*")\s*(?:,\s*(true|false)\s*(?:,\s*/((?:\\.|[^/\n])+)/[a-z]*\s*)?)?[,)]`)
+	globStringPattern     = regexp.MustCompile(`'([^'\n]*)'|"([^"\n]*)"`)
+	matchAllPattern       = regexp.MustCompile(`^\./.*$`)
+)
+
+// parseGlobImports extracts the globs of Vite's import.meta.glob and
+// webpack's require.context from code without comments. Negative patterns of
+// import.meta.glob exclude files from the other patterns of the same call.
+func parseGlobImports(cleanedData []byte) ([]GlobImport, error) {
+	globs := make([]GlobImport, 0)
+
+	for _, match := range importMetaGlobPattern.FindAllSubmatch(cleanedData, -1) {
+		var patterns []string
+		var excludes []string
+		for _, stringMatch := range globStringPattern.FindAllSubmatch(match[1], -1) {
+			pattern := firstGroup(stringMatch)
+			if pattern == "" {
+				continue
+			}
+			if exclude, ok := strings.CutPrefix(pattern, "!"); ok {
+				// Vite resolves globs without a prefix relative to the
+				// importing file
+				if !strings.HasPrefix(exclude, "./") && !strings.HasPrefix(exclude, "../") && !strings.HasPrefix(exclude, "/") {
+					exclude = "./" + exclude
+				}
+				excludes = append(excludes, exclude)
+				continue
+			}
+			patterns = append(patterns, pattern)
+		}
+		for _, pattern := range patterns {
+			glob, err := globToImport(pattern)
+			if err != nil {
+				return nil, err
+			}
+			glob.Excludes = excludes
+			globs = append(globs, glob)
+		}
+	}
+
+	for _, match := range requireContextPattern.FindAllSubmatch(cleanedData, -1) {
+		dir, err := unquoteImportString(match[1])
+		if err != nil {
+			return nil, err
+		}
+		glob := GlobImport{
+			Dir:       strings.TrimSuffix(dir, "/"),
+			Recursive: string(match[2]) != "false",
+			Pattern:   matchAllPattern,
+		}
+		if match[3] != nil {
+			if pattern, err := regexp.Compile(string(match[3])); err == nil {
+				glob.Pattern = pattern
+			} else {
+				glob.UnsupportedFilter = string(match[3])
+			}
+		}
+		globs = append(globs, glob)
+	}
+
+	return globs, nil
+}
+
+// globToImport splits a glob into its static directory and a pattern for
+// the rest of the path, eg. "./pages/**/*.tsx" matches "./a/b.tsx" in "./pages".
+func globToImport(glob string) (GlobImport, error) {
+	segments := strings.Split(glob, "/")
+	i := 0
+	for i < len(segments)-1 && !strings.ContainsAny(segments[i], "*?[{") {
+		i++
+	}
+	dir := strings.Join(segments[:i], "/")
+	if dir == "" && strings.HasPrefix(glob, "/") {
+		dir = "/"
+	}
+
+	rest := strings.Join(segments[i:], "/")
+	compiled, err := regexp.Compile(`^\./` + globPattern(rest) + `$`)
+	if err != nil {
+		return GlobImport{}, fmt.Errorf("compiling glob %s: %v", glob, err)
+	}
+
+	return GlobImport{
+		Dir:       dir,
+		Recursive: strings.Contains(rest, "/") || strings.Contains(rest, "**"),
+		Pattern:   compiled,
+	}, nil
+}
+
+// globToExclude compiles a negative pattern of a glob into a pattern matching
+// the paths of the excluded files.
+func globToExclude(glob string) (*regexp.Regexp, error) {
+	compiled, err := regexp.Compile(`^` + globPattern(glob) + `$`)
+	if err != nil {
+		return nil, fmt.Errorf("compiling glob !%s: %v", glob, err)
+	}
+	return compiled, nil
+}
+
+// globPattern translates the wildcards, classes and braces of a glob into a
+// regular expression.
+func globPattern(glob string) string {
+	var pattern strings.Builder
+	braces := 0
+	for j := 0; j < len(glob); j++ {
+		switch c := glob[j]; {
+		case strings.HasPrefix(glob[j:], "**/"):
+			pattern.WriteString(`(?:.*/)?`)
+			j += 2
+		case strings.HasPrefix(glob[j:], "**"):
+			pattern.WriteString(`.*`)
+			j++
+		case c == '*':
+			pattern.WriteString(`[^/]*`)
+		case c == '?':
+			pattern.WriteString(`[^/]`)
+		case c == '[' && strings.Contains(glob[j:], "]"):
+			end := j + strings.Index(glob[j:], "]")
+			pattern.WriteString(strings.Replace(glob[j:end+1], "[!", "[^", 1))
+			j = end
+		case c == '{':
+			pattern.WriteString(`(?:`)
+			braces++
+		case c == '}' && braces > 0:
+			pattern.WriteString(`)`)
+			braces--
+		case c == ',' && braces > 0:
+			pattern.WriteString(`|`)
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	pattern.WriteString(strings.Repeat(`)`, braces))
+	return pattern.String()
 }
 
 var jestTestPattern = regexp.MustCompile(`(?m)^\s*it\(`)
 
-func parseCodeBlock(data []byte) ([]string, int, error) {
+// parseCodeBlock extracts the imports of code without comments, applying the
+// gazelle comments ending the line of each import.
+func parseCodeBlock(data []byte, comments map[int]importComment) (*ParseResult, error) {
 	dataStr := string(data)
 
 	// Short-circuit: only run expensive regex if we find relevant keywords
@@ -146,54 +490,95 @@ func parseCodeBlock(data []byte) ([]string, int, error) {
 		strings.Contains(dataStr, "require") ||
 		strings.Contains(dataStr, "export") ||
 		strings.Contains(dataStr, "jest") ||
-		strings.Contains(dataStr, "declare")
+		strings.Contains(dataStr, "vi.") ||
+		strings.Contains(dataStr, "declare") ||
+		strings.Contains(dataStr, "import.meta.url")
 
 	imports := make([]string, 0)
+	overrides := make(map[string]string)
 
 	if hasImportKeywords {
-		for _, match := range jsImportPattern.FindAllSubmatch(data, -1) {
+		// line numbers are counted as matches are consumed, since they
+		// never go backwards
+		line, lineStart := 0, 0
+		for _, loc := range jsImportPattern.FindAllSubmatchIndex(data, -1) {
+			match := make([][]byte, len(loc)/2)
+			comment := importComment{}
+			for i := range match {
+				if loc[2*i] < 0 {
+					continue
+				}
+				match[i] = data[loc[2*i]:loc[2*i+1]]
+				if i > 0 {
+					// comments apply to the import whose module ends their line
+					line += bytes.Count(data[lineStart:loc[2*i+1]], []byte("\n"))
+					lineStart = loc[2*i+1]
+					comment = comments[line]
+				}
+			}
+			addImport := func(imp string) {
+				switch {
+				case comment.directive == "ignore":
+				case comment.directive == "resolve" && comment.label != "":
+					overrides[imp] = comment.label
+				default:
+					imports = append(imports, imp)
+				}
+			}
+
 			switch {
 			case match[IMPORT] != nil:
 				unquoted, err := unquoteImportString(match[IMPORT])
 				if err != nil {
-					return nil, 0, fmt.Errorf("unquoting string literal %s from js, %v", match[IMPORT], err)
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[IMPORT], err)
 				}
-				imports = append(imports, unquoted)
+				addImport(unquoted)
 
 			case match[REQUIRE] != nil:
 				unquoted, err := unquoteImportString(match[REQUIRE])
 				if err != nil {
-					return nil, 0, fmt.Errorf("unquoting string literal %s from js, %v", match[REQUIRE], err)
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[REQUIRE], err)
 				}
-				imports = append(imports, unquoted)
+				addImport(unquoted)
 
 			case match[EXPORT] != nil:
 				unquoted, err := unquoteImportString(match[EXPORT])
 				if err != nil {
-					return nil, 0, fmt.Errorf("unquoting string literal %s from js, %v", match[EXPORT], err)
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[EXPORT], err)
 				}
-				imports = append(imports, unquoted)
+				addImport(unquoted)
 
 			case match[JEST_MOCK] != nil:
 				unquoted, err := unquoteImportString(match[JEST_MOCK])
 				if err != nil {
-					return nil, 0, fmt.Errorf("unquoting string literal %s from js, %v", match[JEST_MOCK], err)
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[JEST_MOCK], err)
 				}
-				imports = append(imports, unquoted)
+				addImport(unquoted)
 
 			case match[DYNAMIC_IMPORT] != nil:
 				unquoted, err := unquoteImportString(match[DYNAMIC_IMPORT])
 				if err != nil {
-					return nil, 0, fmt.Errorf("unquoting string literal %s from js, %v", match[DYNAMIC_IMPORT], err)
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[DYNAMIC_IMPORT], err)
 				}
-				imports = append(imports, unquoted)
+				addImport(unquoted)
 
 			case match[DECLARE_MODULE] != nil:
 				unquoted, err := unquoteImportString(match[DECLARE_MODULE])
 				if err != nil {
-					return nil, 0, fmt.Errorf("unquoting string literal %s from js, %v", match[DECLARE_MODULE], err)
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[DECLARE_MODULE], err)
+				}
+				addImport(unquoted)
+
+			case match[NEW_URL] != nil:
+				unquoted, err := unquoteImportString(match[NEW_URL])
+				if err != nil {
+					return nil, fmt.Errorf("unquoting string literal %s from js, %v", match[NEW_URL], err)
+				}
+				// new URL(".", import.meta.url) refers to the module's own directory,
+				// and absolute urls are not files
+				if unquoted != "." && unquoted != "./" && !strings.Contains(unquoted, ":") {
+					addImport(unquoted)
 				}
-				imports = append(imports, unquoted)
 
 			default:
 				// Comment matched. Nothing to extract.
@@ -208,7 +593,11 @@ func parseCodeBlock(data []byte) ([]string, int, error) {
 		jestTestCount = len(jestTestPattern.FindAll(data, -1))
 	}
 
-	return imports, jestTestCount, nil
+	return &ParseResult{
+		Imports:       imports,
+		Overrides:     overrides,
+		JestTestCount: jestTestCount,
+	}, nil
 }
 
 // unquoteImportString takes a string that has a complex quoting around it
@@ -237,3 +626,96 @@ func unquoteImportString(quoted []byte) (string, error) {
 	}
 	return result, err
 }
+
+var (
+	stylesheetBlockCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	stylesheetLineCommentPattern  = regexp.MustCompile(`(?m)(^|[\s;{}])//[^\n]*`)
+	stylesheetImportPattern       = regexp.MustCompile(`@(import|use|forward)\s+([^;{}]+)`)
+	stylesheetStringPattern       = regexp.MustCompile(`"([^"\n]*)"|'([^'\n]*)'`)
+	stylesheetComposesPattern     = regexp.MustCompile(`\bcomposes\s*:[^;{}]*?\bfrom\s+(?:"([^"\n]*)"|'([^'\n]*)')`)
+	stylesheetURLPattern          = regexp.MustCompile(`\burl\(\s*(?:"([^"\n]*)"|'([^'\n]*)'|([^)\s'"]*))\s*\)`)
+)
+
+// ParseStylesheet extracts the files referenced by a CSS, SCSS, Sass or LESS
+// stylesheet. It returns the stylesheets loaded with @import, @use, @forward
+// or a CSS modules composes, and the assets referenced with url(). Remote
+// urls, data uris and builtin sass modules are skipped.
+func ParseStylesheet(data []byte) ([]string, []string) {
+	cleanedData := stylesheetBlockCommentPattern.ReplaceAll(data, []byte(" "))
+	cleanedData = stylesheetLineCommentPattern.ReplaceAll(cleanedData, []byte("$1"))
+
+	stylesheets := make(map[string]bool)
+	for _, match := range stylesheetImportPattern.FindAllSubmatch(cleanedData, -1) {
+		// @import url("a.css") is a stylesheet import as well
+		for _, urlMatch := range stylesheetURLPattern.FindAllSubmatch(match[2], -1) {
+			if ref := firstGroup(urlMatch); isLocalStylesheetRef(ref) {
+				stylesheets[ref] = true
+			}
+		}
+		// @import takes a list of stylesheets, while @use and @forward take
+		// a single one followed by configuration, eg. @use "a" with ($b: "c")
+		limit := -1
+		if string(match[1]) != "import" {
+			limit = 1
+		}
+		withoutURLs := stylesheetURLPattern.ReplaceAll(match[2], nil)
+		for _, stringMatch := range stylesheetStringPattern.FindAllSubmatch(withoutURLs, limit) {
+			if ref := firstGroup(stringMatch); isLocalStylesheetRef(ref) {
+				stylesheets[ref] = true
+			}
+		}
+	}
+
+	for _, match := range stylesheetComposesPattern.FindAllSubmatch(cleanedData, -1) {
+		if ref := firstGroup(match); isLocalStylesheetRef(ref) {
+			stylesheets[ref] = true
+		}
+	}
+
+	withoutImports := stylesheetImportPattern.ReplaceAll(cleanedData, nil)
+	assets := make(map[string]bool)
+	for _, match := range stylesheetURLPattern.FindAllSubmatch(withoutImports, -1) {
+		ref := firstGroup(match)
+		// strip query strings and fragments, eg. font.woff2?v=1 or icons.svg#home
+		if i := strings.IndexAny(ref, "?#"); i >= 0 {
+			ref = ref[:i]
+		}
+		if isLocalStylesheetRef(ref) {
+			assets[ref] = true
+		}
+	}
+
+	return sortedKeys(stylesheets), sortedKeys(assets)
+}
+
+// isLocalStylesheetRef reports whether a stylesheet reference points to a
+// file that could be part of the workspace.
+func isLocalStylesheetRef(ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "#") || strings.Contains(ref, "$") || strings.Contains(ref, "@{") {
+		// absolute paths, fragments and interpolations
+		return false
+	}
+	if i := strings.Index(ref, ":"); i >= 0 && !strings.Contains(ref[:i], "/") {
+		// url schemes and builtin modules, eg. http:, data:, sass:math
+		return false
+	}
+	return true
+}
+
+func firstGroup(match [][]byte) string {
+	for _, group := range match[1:] {
+		if group != nil {
+			return strings.TrimSpace(string(group))
+		}
+	}
+	return ""
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
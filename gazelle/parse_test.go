@@ -148,6 +148,56 @@ if (process.ENV.SHOULD_IMPORT) {
 			const foo = import('dynamic_module2.js')`,
 			want: []string{"dynamic_module.js", "dynamic_module2.js"},
 		},
+		{
+			desc: "jest mock without factory",
+			name: "mock.test.ts",
+			js: `jest.mock('./api');
+jest.mock("jwt-decode", () => ({ default: () => 'token' }));`,
+			want: []string{"./api", "jwt-decode"},
+		},
+		{
+			desc: "jest module registry helpers",
+			name: "registry.test.js",
+			js: `const actual = jest.requireActual('lodash');
+const mocked = jest.requireMock('./service');
+jest.doMock('../config', () => ({}));
+jest.unstable_mockModule('node:child_process', () => ({ execSync: jest.fn() }));
+beforeEach(() => {
+  jest.mock(
+    './multiline',
+  );
+});`,
+			want: []string{"../config", "./multiline", "./service", "lodash", "node:child_process"},
+		},
+		{
+			desc: "vitest mocks",
+			name: "vitest.test.ts",
+			js: `import { vi } from 'vitest'
+vi.mock('./db')
+vi.doMock('axios', async () => ({ ...(await vi.importActual('axios')) }))
+const mod = await vi.importActual<typeof import('./utils')>('./utils')
+const stub = await vi.importMock("./stub")`,
+			want: []string{"./db", "./stub", "./utils", "axios", "vitest"},
+		},
+		{
+			desc: "new URL relative to import.meta.url",
+			name: "worker.ts",
+			js: `const worker = new Worker(new URL('./worker.ts', import.meta.url), { type: 'module' })
+const wasm = await fetch(new URL("../pkg/module_bg.wasm", import.meta.url))
+const shared = new SharedWorker(
+  new URL('./shared-worker.js', import.meta.url),
+)
+const here = new URL('.', import.meta.url)
+const api = new URL('https://example.com/api', import.meta.url)
+const page = new URL('./page.html', location.href)`,
+			want: []string{"../pkg/module_bg.wasm", "./shared-worker.js", "./worker.ts"},
+		},
+		{
+			desc: "new URL after an import on the same line",
+			name: "same-line.ts",
+			js:   `import w from './a'; const u = new URL('./b', import.meta.url)`,
+			want: []string{"./a", "./b"},
+		},
 		{
 			desc: "declare module augmentation",
 			name: "augmentation.tsx",
@@ -177,17 +227,448 @@ declare module '@mui/material/styles' {
 }`,
 			want: []string{"@mui/material/styles", "@mui/material/styles"},
 		},
+		{
+			desc: "inline gazelle comments",
+			name: "comments.ts",
+			js: `import { a } from "./a"
+import { api } from "server-only" // gazelle:resolve //server:api
+import {
+  b,
+} from "./b" // gazelle:ignore
+const optional = require("optional-dep") // gazelle:ignore
+// gazelle:ignore
+import { c } from "./c"`,
+			want: []string{"./a", "./c"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+
+			result, err := ParseJS([]byte(tc.js))
+			if err != nil {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if !reflect.DeepEqual(result.Imports, tc.want) {
+				t.Errorf("Inequalith.\ngot  %#v;\nwant %#v", result.Imports, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseStylesheet(t *testing.T) {
+	for _, tc := range []struct {
+		desc, css         string
+		stylesheets, data []string
+	}{
+		{
+			desc:        "empty",
+			css:         "",
+			stylesheets: []string{},
+			data:        []string{},
+		},
+		{
+			desc: "css imports",
+			css: `@import "reset.css";
+@import url("./theme.css") screen;
+@import url(print.css) print, 'fonts.css';`,
+			stylesheets: []string{"./theme.css", "fonts.css", "print.css", "reset.css"},
+			data:        []string{},
+		},
+		{
+			desc: "sass modules",
+			css: `@use "sass:math";
+@use "../theme/vars" as v;
+@use "config" with ($primary: "blue");
+@forward "src/list" hide list-reset;
+@import "~bootstrap/scss/functions", "mixins";`,
+			stylesheets: []string{"../theme/vars", "config", "mixins", "src/list", "~bootstrap/scss/functions"},
+			data:        []string{},
+		},
+		{
+			desc:        "less import options",
+			css:         `@import (reference) "../shared/colors.less";`,
+			stylesheets: []string{"../shared/colors.less"},
+			data:        []string{},
+		},
+		{
+			desc: "urls",
+			css: `.logo { background: url("../images/logo.png") no-repeat; }
+@font-face { src: url(fonts/inter.woff2?v=3) format("woff2"), url('fonts/inter.woff#iefix'); }
+.icon { background-image:url(https://cdn.example.com/icon.svg); }
+.inline { background: url("data:image/svg+xml;utf8,<svg></svg>"); }
+.gradient { fill: url(#gradient); }
+.root { background: url(/static/bg.png); }`,
+			stylesheets: []string{},
+			data:        []string{"../images/logo.png", "fonts/inter.woff", "fonts/inter.woff2"},
+		},
+		{
+			desc: "css modules composition",
+			css: `.primary {
+  composes: button from "./Button.module.css";
+  composes: large bold from './typography.module.scss';
+  composes: local;
+}`,
+			stylesheets: []string{"./Button.module.css", "./typography.module.scss"},
+			data:        []string{},
+		},
+		{
+			desc: "ignores comments",
+			css: `/* @import "old.css"; */
+// @use "legacy";
+.a { background: url(http://example.com/a.png); } // url(b.png)
+@import "kept.scss"; // trailing comment`,
+			stylesheets: []string{"kept.scss"},
+			data:        []string{},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			stylesheets, data := ParseStylesheet([]byte(tc.css))
+
+			if !reflect.DeepEqual(stylesheets, tc.stylesheets) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", stylesheets, tc.stylesheets)
+			}
+			if !reflect.DeepEqual(data, tc.data) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", data, tc.data)
+			}
+		})
+	}
+}
+
+func TestParseSFC(t *testing.T) {
+	for _, tc := range []struct {
+		desc, sfc string
+		want      []string
+		overrides map[string]string
+	}{
+		{
+			desc: "empty",
+			sfc:  "",
+			want: []string{},
+		},
+		{
+			desc: "vue script setup",
+			sfc: `<template>
+  <Button :label="label" />
+</template>
+
+<script setup lang="ts">
+import { ref } from 'vue'
+import Button from './Button.vue'
+// import Unused from './Unused.vue'
+
+const label = ref('Hello')
+</script>
+
+<style scoped src="./card.css"></style>`,
+			want: []string{"./Button.vue", "./card.css", "vue"},
+		},
+		{
+			desc: "vue script and script setup",
+			sfc: `<script>
+export { default as helpers } from "./helpers"
+</script>
+<script setup>
+import { useStore } from '@/stores/main'
+</script>`,
+			want: []string{"./helpers", "@/stores/main"},
+		},
+		{
+			desc: "external script",
+			sfc: `<template><div /></template>
+<script src='./component.ts'></script>`,
+			want: []string{"./component.ts"},
+		},
+		{
+			desc: "svelte module context",
+			sfc: `<script context="module" lang="ts">
+  export const prerender = true
+  import type { Load } from '@sveltejs/kit'
+</script>
+
+<script lang="ts">
+  import { onMount } from 'svelte'
+  import Nav from '$lib/Nav.svelte'
+</script>
+
+<Nav />
+
+<style lang="scss">
+  .nav { color: red; }
+</style>`,
+			want: []string{"$lib/Nav.svelte", "@sveltejs/kit", "svelte"},
+		},
+		{
+			desc: "gazelle comments",
+			sfc: `<script setup lang="ts">
+  import { api } from '#server/api' // gazelle:resolve //server:api
+  import Chart from './Chart.vue' // gazelle:ignore
+  import Nav from './Nav.vue'
+</script>`,
+			want:      []string{"./Nav.vue"},
+			overrides: map[string]string{"#server/api": "//server:api"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := ParseSFC([]byte(tc.sfc))
+			if err != nil {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if !reflect.DeepEqual(result.Imports, tc.want) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", result.Imports, tc.want)
+			}
+			if len(result.Overrides) > 0 || tc.overrides != nil {
+				if !reflect.DeepEqual(result.Overrides, tc.overrides) {
+					t.Errorf("Inequality.\ngot  %#v;\nwant %#v", result.Overrides, tc.overrides)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMDX(t *testing.T) {
+	for _, tc := range []struct {
+		desc, mdx string
+		want      []string
+	}{
+		{
+			desc: "empty",
+			mdx:  "",
+			want: []string{},
+		},
+		{
+			desc: "esm blocks",
+			mdx: `import { Chart } from '../components/chart'
+import Intro from './intro.mdx'
+export { Layout as default } from "@/layouts/docs"
+
+# Getting started
+
+<Intro />
+
+Here's how to import things in prose: import x from "not-an-import"
+
+export const meta = {
+  title: 'Getting started',
+}
+`,
+			want: []string{"../components/chart", "./intro.mdx", "@/layouts/docs"},
+		},
+		{
+			desc: "ignores fenced code",
+			mdx:  "# Usage\n\n```js\nimport { Button } from 'my-ui'\n```\n\n~~~tsx\nexport * from './example'\n~~~\n\nimport Real from './real'\n",
+			want: []string{"./real"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := ParseMDX([]byte(tc.mdx))
+			if err != nil {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if !reflect.DeepEqual(result.Imports, tc.want) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", result.Imports, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseGlobImports(t *testing.T) {
+	type globCase struct {
+		dir         string
+		recursive   bool
+		matches     []string
+		misses      []string
+		excludes    []string
+		unsupported string
+	}
+	for _, tc := range []struct {
+		desc, js string
+		want     []globCase
+	}{
+		{
+			desc: "empty",
+			js:   "",
+			want: []globCase{},
+		},
+		{
+			desc: "vite glob",
+			js:   `const pages = import.meta.glob('./pages/*.tsx')`,
+			want: []globCase{
+				{dir: "./pages", recursive: false, matches: []string{"./index.tsx"}, misses: []string{"./index.ts", "./nested/index.tsx"}},
+			},
+		},
+		{
+			desc: "vite glob list with options",
+			js: `const modules = import.meta.glob<Module>(
+  ["./routes/**/*.{ts,tsx}", "!./routes/**/*.test.tsx", "/src/locales/?.json"],
+  { eager: true },
+)`,
+			want: []globCase{
+				{dir: "./routes", recursive: true, matches: []string{"./a.ts", "./b/c.tsx"}, misses: []string{"./a.js"}, excludes: []string{"./routes/**/*.test.tsx"}},
+				{dir: "/src/locales", recursive: false, matches: []string{"./x.json"}, misses: []string{"./en.json"}, excludes: []string{"./routes/**/*.test.tsx"}},
+			},
+		},
+		{
+			desc: "vite glob with bare negative pattern",
+			js:   `const icons = import.meta.glob(['./icons/*.svg', '!**/draft-*.svg'])`,
+			want: []globCase{
+				{dir: "./icons", recursive: false, matches: []string{"./home.svg"}, excludes: []string{"./**/draft-*.svg"}},
+			},
+		},
+		{
+			desc: "webpack context",
+			js: `const icons = require.context('./icons', false, /\.svg$/)
+const all = require.context("../assets")
+// const ignored = require.context('./ignored', true, /\.png$/)`,
+			want: []globCase{
+				{dir: "./icons", recursive: false, matches: []string{"./home.svg"}, misses: []string{"./home.png"}},
+				{dir: "../assets", recursive: true, matches: []string{"./a/b.png"}},
+			},
+		},
+		{
+			desc: "webpack context with unsupported filter",
+			js:   `const icons = require.context('./icons', true, /^(?!.*test).*\.svg$/)`,
+			want: []globCase{
+				{dir: "./icons", recursive: true, matches: []string{"./home.svg", "./home.test.svg"}, unsupported: `^(?!.*test).*\.svg$`},
+			},
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
+			result, err := ParseJS([]byte(tc.js))
+			if err != nil {
+				t.Error(err)
+				t.FailNow()
+			}
+			if len(result.Globs) != len(tc.want) {
+				t.Fatalf("got %d globs, want %d", len(result.Globs), len(tc.want))
+			}
+			for i, want := range tc.want {
+				if result.Globs[i].Dir != want.dir || result.Globs[i].Recursive != want.recursive {
+					t.Errorf("Inequality.\ngot  %s %v;\nwant %s %v", result.Globs[i].Dir, result.Globs[i].Recursive, want.dir, want.recursive)
+				}
+				if result.Globs[i].UnsupportedFilter != want.unsupported {
+					t.Errorf("Inequality.\ngot  %s;\nwant %s", result.Globs[i].UnsupportedFilter, want.unsupported)
+				}
+				if !reflect.DeepEqual(result.Globs[i].Excludes, want.excludes) {
+					t.Errorf("Inequality.\ngot  %#v;\nwant %#v", result.Globs[i].Excludes, want.excludes)
+				}
+				for _, match := range want.matches {
+					if !result.Globs[i].Pattern.MatchString(match) {
+						t.Errorf("pattern %v does not match %s", result.Globs[i].Pattern, match)
+					}
+				}
+				for _, miss := range want.misses {
+					if result.Globs[i].Pattern.MatchString(miss) {
+						t.Errorf("pattern %v matches %s", result.Globs[i].Pattern, miss)
+					}
+				}
+			}
+		})
+	}
+}
 
-			imports, _, err := ParseJS([]byte(tc.js))
+func TestParseReferences(t *testing.T) {
+	for _, tc := range []struct {
+		desc, ts     string
+		paths, types []string
+	}{
+		{
+			desc:  "empty",
+			ts:    "",
+			paths: []string{},
+			types: []string{},
+		},
+		{
+			desc: "references",
+			ts: `/// <reference types="vite/client" />
+///<reference types='node'/>
+/// <reference path="../globals.d.ts" />
+/// <reference path="env.d.ts" />
+import { a } from "./a";`,
+			paths: []string{"../globals.d.ts", "./env.d.ts"},
+			types: []string{"node", "vite/client"},
+		},
+		{
+			desc: "ignores other directives and comments",
+			ts: `/// <reference lib="es2017.string" />
+/// <amd-module name="NamedModule"/>
+// <reference types="jest" />
+const reference = '/// <reference types="react" />';`,
+			paths: []string{},
+			types: []string{},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			paths, types := ParseReferences([]byte(tc.ts))
+
+			if !reflect.DeepEqual(paths, tc.paths) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", paths, tc.paths)
+			}
+			if !reflect.DeepEqual(types, tc.types) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", types, tc.types)
+			}
+		})
+	}
+}
+
+func TestParseImportComments(t *testing.T) {
+	for _, tc := range []struct {
+		desc, js  string
+		want      []string
+		overrides map[string]string
+	}{
+		{
+			desc:      "empty",
+			js:        "",
+			want:      []string{},
+			overrides: map[string]string{},
+		},
+		{
+			desc: "comments",
+			js: `import { a } from "./a" // gazelle:ignore
+import b from './b' //gazelle:resolve :b_lib
+export * from "./c" // gazelle:resolve
+const url = "http://example.com" // comment
+if (isServer) require("fs-extra") // gazelle:ignore optional on the client`,
+			want:      []string{"./c"},
+			overrides: map[string]string{"./b": ":b_lib"},
+		},
+		{
+			desc: "only the import of the line",
+			js: `import { a } from "./a" // gazelle:ignore
+const lazy = () => import("./a")
+const name = "./d" // gazelle:ignore
+import { d } from "./d"`,
+			want:      []string{"./a", "./d"},
+			overrides: map[string]string{},
+		},
+		{
+			desc: "after block comments",
+			js: `/*
+ * License
+ */
+import { e } from "./e" // gazelle:resolve //e
+import { f } from "./f"`,
+			want:      []string{"./f"},
+			overrides: map[string]string{"./e": "//e"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := ParseJS([]byte(tc.js))
 			if err != nil {
 				t.Error(err)
 				t.FailNow()
 			}
 
-			if !reflect.DeepEqual(imports, tc.want) {
-				t.Errorf("Inequalith.\ngot  %#v;\nwant %#v", imports, tc.want)
+			if !reflect.DeepEqual(result.Imports, tc.want) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", result.Imports, tc.want)
+			}
+			if !reflect.DeepEqual(result.Overrides, tc.overrides) {
+				t.Errorf("Inequality.\ngot  %#v;\nwant %#v", result.Overrides, tc.overrides)
 			}
 		})
 	}
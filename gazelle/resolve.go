@@ -17,11 +17,13 @@
 package js
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
@@ -29,52 +31,9 @@ import (
 	"github.com/bazelbuild/bazel-gazelle/repo"
 	"github.com/bazelbuild/bazel-gazelle/resolve"
 	"github.com/bazelbuild/bazel-gazelle/rule"
+	bzl "github.com/bazelbuild/buildtools/build"
 )
 
-// BUILTINS list taken from https://github.com/sindresorhus/builtin-modules/blob/master/builtin-modules.json
-var BUILTINS = map[string]bool{
-	"assert":         true,
-	"async_hooks":    true,
-	"buffer":         true,
-	"child_process":  true,
-	"cluster":        true,
-	"console":        true,
-	"constants":      true,
-	"crypto":         true,
-	"dgram":          true,
-	"dns":            true,
-	"domain":         true,
-	"events":         true,
-	"fs":             true,
-	"http":           true,
-	"http2":          true,
-	"https":          true,
-	"inspector":      true,
-	"module":         true,
-	"net":            true,
-	"os":             true,
-	"path":           true,
-	"perf_hooks":     true,
-	"process":        true,
-	"punycode":       true,
-	"querystring":    true,
-	"readline":       true,
-	"repl":           true,
-	"stream":         true,
-	"string_decoder": true,
-	"timers":         true,
-	"tls":            true,
-	"trace_events":   true,
-	"tty":            true,
-	"url":            true,
-	"util":           true,
-	"v8":             true,
-	"vm":             true,
-	"wasi":           true,
-	"worker_threads": true,
-	"zlib":           true,
-}
-
 // maps resolve.Resolver -> *JS
 // Resolver is an interface that language extensions can implement to resolve
 // dependencies in rules they generate.
@@ -89,6 +48,11 @@ func (lang *JS) Imports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.
 	jsConfigs := c.Exts[languageName].(JsConfigs)
 	jsConfig := jsConfigs[f.Pkg]
 
+	// generated sources can be imported through the files a rule outputs
+	if r.Kind() == getKind(c, "genrule") || r.Kind() == getKind(c, "ts_proto_library") {
+		return lang.generatedImports(c, r, f)
+	}
+
 	srcs := r.AttrStrings("srcs")
 
 	importSpecs := make([]resolve.ImportSpec, 0)
@@ -112,7 +76,7 @@ func (lang *JS) Imports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.
 	}
 
 	// modules can be resolved via the directory containing them
-	if (isBarrel || jsConfig.CollectAll) && r.Kind() != getKind(c, "jest_test") {
+	if (isBarrel || jsConfig.CollectAll) && !lang.isTestKind(c, r.Kind()) && !lang.isFileKind(c, r.Kind()) {
 		importSpecs = append(importSpecs, resolve.ImportSpec{
 			Lang: lang.Name(),
 			Imp:  f.Pkg,
@@ -142,6 +106,48 @@ func (lang *JS) Imports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.
 	return importSpecs
 }
 
+// generatedImports returns an ImportSpec for each file generated by a code
+// generation rule. Rules with outs, like genrule, are indexed by those. For
+// ts_proto_library, the outputs of protoc-gen-es and protoc-gen-connect-es are
+// derived from proto_srcs, or the .proto files of the package.
+func (lang *JS) generatedImports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.ImportSpec {
+
+	outs := r.AttrStrings("outs")
+	if out := r.AttrString("out"); out != "" {
+		outs = append(outs, out)
+	}
+
+	if r.Kind() == getKind(c, "ts_proto_library") {
+		protoSrcs := r.AttrStrings("proto_srcs")
+		if len(protoSrcs) == 0 {
+			protoFiles, _ := filepath.Glob(filepath.Join(c.RepoRoot, f.Pkg, "*.proto"))
+			for _, protoFile := range protoFiles {
+				protoSrcs = append(protoSrcs, filepath.Base(protoFile))
+			}
+		}
+		suffixes := []string{"_pb"}
+		if genConnect, ok := r.Attr("gen_connect_es").(*bzl.Ident); ok && genConnect.Name == "True" {
+			suffixes = append(suffixes, "_connect")
+		}
+		for _, protoSrc := range protoSrcs {
+			for _, suffix := range suffixes {
+				base := strings.TrimSuffix(protoSrc, ".proto") + suffix
+				outs = append(outs, base+".js", base+".d.ts")
+			}
+		}
+	}
+
+	importSpecs := make([]resolve.ImportSpec, 0, len(outs))
+	for _, out := range outs {
+		importSpecs = append(importSpecs, resolve.ImportSpec{
+			Lang: lang.Name(),
+			Imp:  path.Join(f.Pkg, out),
+		})
+	}
+
+	return importSpecs
+}
+
 // Embeds returns a list of labels of rules that the given rule embeds. If
 // a rule is embedded by another importable rule of the same language, only
 // the embedding rule will be indexed. The embedding rule will inherit
@@ -179,80 +185,78 @@ func (lang *JS) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Remote
 	}
 
 	imports := _imports.(*imports)
-	depSet := make(map[string]bool)
-	dataSet := make(map[string]bool)
-	for name := range imports.set {
 
-		// is it a package.json import?
-		if name == "package" || name == "package.json" {
-			depSet[packageJSON] = true
-			continue
+	// expand glob imports to the files they match
+	names := make(map[string]bool)
+	for name := range imports.set {
+		names[name] = true
+	}
+	for _, glob := range imports.globs {
+		for _, name := range lang.expandGlobImport(glob, c, from) {
+			names[name] = true
 		}
-
-		// fix aliases
-		match := jsConfig.ImportAliasPattern.FindStringSubmatch(name)
-		if len(match) > 0 {
-			prefix := match[0]
-			alias := ""
-			for _, impAlias := range jsConfig.ImportAliases {
-				if impAlias.From == prefix {
-					alias = impAlias.To
-					break
-				}
-			}
-
-			name = alias + strings.TrimPrefix(name, prefix)
+	}
+	// implicit runtime imports would only warn when the package is not installed
+	for name := range imports.runtimes {
+		if isNpm, _, _ := lang.isNpmDependency(name, jsConfig); isNpm {
+			names[name] = true
 		}
+	}
 
-		// is it an npm dependency?
-		isNpm, npmLabel, devDep := lang.isNpmDependency(name, jsConfig)
-		if isNpm {
-
-			s := strings.Split(name, "/")
-			name = s[0]
-			if strings.HasPrefix(name, "@") && len(s) >= 2 {
-				name += "/" + s[1]
-			}
-			depSet[fmt.Sprintf("%s%s", npmLabel, name)] = true
-			if !devDep {
-				// Runtime dependency
-				dataSet[fmt.Sprintf("%s%s", npmLabel, name)] = true
+	depSet := make(map[string]bool)
+	dataSet := make(map[string]bool)
+	for name := range names {
+		nameDeps := make(map[string]bool)
+		nameData := make(map[string]bool)
+		lang.resolveImport(name, packageJSON, nameDeps, nameData, c, ix, rc, r, from)
+		for dep := range nameDeps {
+			if !checkBoundaries(dep, name, jsConfig, from) {
+				continue
 			}
-
-			if jsConfig.LookupTypes && r.Kind() == "ts_project" {
-				// does it have a corresponding @types/[...] declaration?
-				typesFound, npmLabel, _ := lang.isNpmDependency("@types/"+name, jsConfig)
-				if typesFound {
-					depSet[fmt.Sprintf("%s@types/%s", npmLabel, name)] = true
-				}
+			depSet[dep] = true
+			lang.graph.addEdge(from, dep, "deps", name)
+		}
+		for d := range nameData {
+			if !checkBoundaries(d, name, jsConfig, from) {
+				continue
 			}
+			dataSet[d] = true
+			lang.graph.addEdge(from, d, "data", name)
+		}
+	}
 
+	// Imports with a gazelle:resolve comment
+	for name, override := range imports.overrides {
+		lbl, err := label.Parse(override)
+		if err != nil {
+			log.Print(Err("[%s] invalid gazelle:resolve label %q for import %v: %v", from.Abs(from.Repo, from.Pkg).String(), override, name, err))
 			continue
 		}
-
-		// is it a builtin?
-		if _, ok := BUILTINS[name]; ok || strings.HasPrefix(name, "node:") {
-			// add @types/node when using node.js builtin and have @types/nodes installed
-			if jsConfig.LookupTypes && r.Kind() == "ts_project" {
-				typesFound, npmLabel, _ := lang.isNpmDependency("@types/node", jsConfig)
-				if typesFound {
-					depSet[fmt.Sprintf("%s@types/node", npmLabel)] = true
-				}
-			}
+		lbl = lbl.Abs(from.Repo, from.Pkg)
+		if lbl.Equal(from) {
 			continue
 		}
+		depSet[lbl.Rel(from.Repo, from.Pkg).String()] = true
+		lang.graph.addEdge(from, graphLabel(lbl, from.Repo), "deps", name)
+	}
 
-		// Is user resolved
-		resolveResult := lang.tryResolve(name, c, ix, from)
-		if resolveResult.err == nil && !resolveResult.selfImport && resolveResult.label != label.NoLabel {
-			// add discovered label
-			lbl := resolveResult.label
-			dep := lbl.Rel(from.Repo, from.Pkg).String()
-			depSet[dep] = true
+	// Type packages referenced with /// <reference types="..." /> are only
+	// needed at compile time, ie. @types/node or vite/client
+	for name := range imports.types {
+		packageName := npmPackageName(name)
+		if typesLabel, ok := lang.lookupTypes(packageName, c, jsConfig); ok {
+			depSet[typesLabel] = true
+			lang.graph.addEdge(from, typesLabel, "deps", name)
 			continue
 		}
-
-		lang.resolveWalkParents(name, depSet, dataSet, c, ix, rc, r, from)
+		if isNpm, npmLabel, _ := lang.isNpmDependency(name, jsConfig); isNpm {
+			depSet[fmt.Sprintf("%s%s", npmLabel, packageName)] = true
+			lang.graph.addEdge(from, fmt.Sprintf("%s%s", npmLabel, packageName), "deps", name)
+			continue
+		}
+		if !jsConfig.Quiet {
+			log.Print(Err("[%s] type reference %v not found", from.Abs(from.Repo, from.Pkg).String(), name))
+		}
 	}
 
 	// Add in additional jest dependencies
@@ -282,6 +286,30 @@ func (lang *JS) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Remote
 		dataSet[fmt.Sprintf("//%s:package_json", packageLocation)] = true
 	}
 
+	// Add in the runner config for e2e tests
+	e2eConfigs := map[string]string{
+		getKind(c, "playwright_test"): jsConfig.PlaywrightConfig,
+		getKind(c, "cypress_test"):    jsConfig.CypressConfig,
+	}
+	if configFile, ok := e2eConfigs[r.Kind()]; ok {
+		// All deps are also data for e2e test rules.
+		for name := range depSet {
+			dataSet[name] = true
+		}
+		if configFile != "" {
+			resolveResult := lang.tryResolve(configFile, c, ix, from)
+			if resolveResult.err == nil && !resolveResult.selfImport && resolveResult.label != label.NoLabel {
+				dataSet[resolveResult.label.Rel(from.Repo, from.Pkg).String()] = true
+			} else if resolveResult.fileName != "" {
+				lbl := label.New("", path.Dir(configFile), resolveResult.fileName)
+				if lbl.Pkg == "." {
+					lbl.Pkg = ""
+				}
+				dataSet[lbl.Rel(from.Repo, from.Pkg).String()] = true
+			}
+		}
+	}
+
 	// Add in page dependencies if they exist
 	if r.Name() == jsConfig.CollectTargets {
 		for fqName := range jsConfig.CollectedTargets {
@@ -294,6 +322,17 @@ func (lang *JS) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Remote
 		}
 	}
 
+	lang.graph.addNode(from, r, jsConfig)
+	if jsConfig.AutoVisibility && !lang.isTestKind(c, r.Kind()) {
+		lang.graph.addAutoVisibility(from, r, jsConfig.Visibility.Labels)
+	}
+	for dep := range depSet {
+		lang.graph.addConsumer(from, dep)
+	}
+	for d := range dataSet {
+		lang.graph.addConsumer(from, d)
+	}
+
 	deps := []string{}
 	for dep := range depSet {
 		deps = append(deps, dep)
@@ -315,6 +354,116 @@ func (lang *JS) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Remote
 	}
 }
 
+// resolveImport resolves a single import of the rule r into deps and data.
+func (lang *JS) resolveImport(name string, packageJSON string, depSet map[string]bool, dataSet map[string]bool, c *config.Config, ix *resolve.RuleIndex, rc *repo.RemoteCache, r *rule.Rule, from label.Label) {
+
+	jsConfigs := c.Exts[languageName].(JsConfigs)
+	jsConfig := jsConfigs[from.Pkg]
+
+	// is it ignored?
+	if isIgnoredImport(name, jsConfig) {
+		return
+	}
+
+	// is it a package.json import?
+	if name == "package" || name == "package.json" {
+		depSet[packageJSON] = true
+		return
+	}
+
+	// fix aliases
+	match := jsConfig.ImportAliasPattern.FindStringSubmatch(name)
+	if len(match) > 0 {
+		prefix := match[0]
+		alias := ""
+		for _, impAlias := range jsConfig.ImportAliases {
+			if impAlias.From == prefix {
+				alias = impAlias.To
+				break
+			}
+		}
+
+		name = alias + strings.TrimPrefix(name, prefix)
+	}
+
+	// is it mapped with js_resolve? these take precedence over npm packages
+	if pattern, ok := findResolvePattern(name, jsConfig); ok {
+		lbl, err := label.Parse(pattern)
+		if err != nil {
+			log.Print(Err("[%s] invalid js_resolve label for %s: %v", from.Abs(from.Repo, from.Pkg).String(), name, err))
+		} else if !lbl.Abs(from.Repo, from.Pkg).Equal(from) {
+			depSet[lbl.Rel(from.Repo, from.Pkg).String()] = true
+		}
+		return
+	}
+
+	// does it live in another repository?
+	if lbl, ok := resolveExternalRepo(name, jsConfig); ok {
+		depSet[lbl.String()] = true
+		return
+	}
+
+	// is it an npm dependency?
+	isNpm, npmLabel, devDep := lang.isNpmDependency(name, jsConfig)
+	if isNpm {
+
+		name = npmPackageName(name)
+		checkInstalled(name, jsConfig, from)
+		depSet[fmt.Sprintf("%s%s", npmLabel, name)] = true
+		if !devDep {
+			// Runtime dependency
+			dataSet[fmt.Sprintf("%s%s", npmLabel, name)] = true
+		}
+
+		if jsConfig.LookupTypes && r.Kind() == "ts_project" {
+			// does it have a corresponding @types/[...] declaration?
+			if typesLabel, ok := lang.lookupTypes(name, c, jsConfig); ok {
+				depSet[typesLabel] = true
+			}
+		}
+
+		return
+	}
+
+	// is it a builtin?
+	if typesName, ok := isBuiltin(name, jsConfig); ok {
+		// add the types of the runtime, ie. @types/node, when they are installed
+		if typesName != "" && jsConfig.LookupTypes && r.Kind() == "ts_project" {
+			typesFound, npmLabel, _ := lang.isNpmDependency(typesName, jsConfig)
+			if typesFound {
+				depSet[fmt.Sprintf("%s%s", npmLabel, typesName)] = true
+			}
+		}
+		return
+	}
+
+	// Is user resolved
+	resolveResult := lang.tryResolve(name, c, ix, from)
+	if resolveResult.err == nil && !resolveResult.selfImport && resolveResult.label != label.NoLabel {
+		// add discovered label
+		lbl := resolveResult.label
+		dep := lbl.Rel(from.Repo, from.Pkg).String()
+		depSet[dep] = true
+		return
+	}
+
+	if r.Kind() == getKind(c, "web_assets") || r.Kind() == getKind(c, "css_module") {
+		// Imported stylesheets are deps, other referenced assets are data
+		assetSet := make(map[string]bool)
+		lang.resolveWalkParents(name, assetSet, assetSet, c, ix, rc, r, from)
+		for asset := range assetSet {
+			if isStylesheet(name) {
+				depSet[asset] = true
+			} else {
+				dataSet[asset] = true
+			}
+		}
+		return
+	}
+
+	lang.resolveWalkParents(name, depSet, dataSet, c, ix, rc, r, from)
+}
+
 func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet map[string]bool, c *config.Config, ix *resolve.RuleIndex, rc *repo.RemoteCache, r *rule.Rule, from label.Label) {
 
 	jsConfigs := c.Exts[languageName].(JsConfigs)
@@ -333,6 +482,10 @@ func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet
 		}
 
 		localDir := path.Join(from.Pkg, parents)
+		if localDir == "" {
+			// the root package
+			localDir = "."
+		}
 		target := path.Join(localDir, name)
 
 		// add supported extensions to target name to get a filePath
@@ -360,7 +513,7 @@ func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet
 				// add discovered label
 				lbl := resolveResult.label
 				dep := lbl.Rel(from.Repo, from.Pkg).String()
-				if !lang.isWebAsset(jsConfig, filePath) {
+				if !lang.isWebAsset(jsConfig, filePath) || isCSSModule(filePath) {
 					depSet[dep] = true
 				} else {
 					dataSet[dep] = true
@@ -370,6 +523,9 @@ func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet
 			if resolveResult.fileName != "" {
 				// add discovered file
 				pkgName := path.Dir(target)
+				if pkgName == "." {
+					pkgName = ""
+				}
 				data := fmt.Sprintf("//%s:%s", pkgName, resolveResult.fileName)
 				dataSet[data] = true
 				return
@@ -393,7 +549,7 @@ func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet
 			if resolveResult.label != label.NoLabel {
 				lbl := resolveResult.label
 				dep := lbl.Rel(from.Repo, from.Pkg).String()
-				if !lang.isWebAsset(jsConfig, filePath) {
+				if !lang.isWebAsset(jsConfig, filePath) || isCSSModule(filePath) {
 					depSet[dep] = true
 				} else {
 					dataSet[dep] = true
@@ -402,6 +558,9 @@ func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet
 			}
 			if resolveResult.fileName != "" {
 				pkgName := path.Dir(indexTarget)
+				if pkgName == "." {
+					pkgName = ""
+				}
 				data := fmt.Sprintf("//%s:%s", pkgName, resolveResult.fileName)
 				dataSet[data] = true
 				return
@@ -428,6 +587,154 @@ func (lang *JS) resolveWalkParents(name string, depSet map[string]bool, dataSet
 
 }
 
+// expandGlobImport lists the files matching a glob import, as imports
+// relative to the package of the importing rule.
+func (lang *JS) expandGlobImport(glob GlobImport, c *config.Config, from label.Label) []string {
+
+	jsConfigs := c.Exts[languageName].(JsConfigs)
+	jsConfig := jsConfigs[from.Pkg]
+
+	var dir string
+	switch {
+	case strings.HasPrefix(glob.Dir, "/"):
+		// absolute globs start from the project root
+		dir = path.Join(jsConfig.JSRoot, glob.Dir)
+	case strings.HasPrefix(glob.Dir, ".") || glob.Dir == "":
+		dir = path.Join(from.Pkg, glob.Dir)
+	default:
+		// aliased globs are not supported
+		if !jsConfig.Quiet {
+			log.Print(Warn("[%s] unable to expand glob import in %s", from.Abs(from.Repo, from.Pkg).String(), glob.Dir))
+		}
+		return nil
+	}
+
+	if glob.UnsupportedFilter != "" && !jsConfig.Quiet {
+		log.Print(Warn("[%s] unsupported require.context filter /%s/, including every file in %s", from.Abs(from.Repo, from.Pkg).String(), glob.UnsupportedFilter, glob.Dir))
+	}
+
+	excludes := make([]*regexp.Regexp, 0, len(glob.Excludes))
+	for _, exclude := range glob.Excludes {
+		pattern, err := globToExclude(exclude)
+		if err != nil {
+			log.Print(Err("[%s] %v", from.Abs(from.Repo, from.Pkg).String(), err))
+			continue
+		}
+		excludes = append(excludes, pattern)
+	}
+
+	names := []string{}
+	root := filepath.Join(c.RepoRoot, dir)
+	filepath.WalkDir(root, func(filePath string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if filePath != root && (!glob.Recursive || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil || !glob.Pattern.MatchString("./"+filepath.ToSlash(relPath)) {
+			return nil
+		}
+		name, err := filepath.Rel(filepath.Join(c.RepoRoot, from.Pkg), filePath)
+		if err != nil {
+			return nil
+		}
+		name = filepath.ToSlash(name)
+		if !strings.HasPrefix(name, ".") {
+			name = "./" + name
+		}
+		rootPath, err := filepath.Rel(filepath.Join(c.RepoRoot, jsConfig.JSRoot), filePath)
+		if err != nil {
+			return nil
+		}
+		for _, exclude := range excludes {
+			if exclude.MatchString(name) || exclude.MatchString("/"+filepath.ToSlash(rootPath)) {
+				return nil
+			}
+		}
+		names = append(names, name)
+		return nil
+	})
+
+	return names
+}
+
+// isTestKind reports whether kind is one of the test rules generated by this
+// extension. Test rules are never imported by other rules.
+func (lang *JS) isTestKind(c *config.Config, kind string) bool {
+	for _, testKind := range []string{"jest_test", "playwright_test", "cypress_test"} {
+		if kind == getKind(c, testKind) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFileKind reports whether rules of kind are only imported through their
+// files, rather than through the directory containing them.
+func (lang *JS) isFileKind(c *config.Config, kind string) bool {
+	for _, fileKind := range []string{"storybook_stories", "css_module", "vue_component", "svelte_component", "mdx_document"} {
+		if kind == getKind(c, fileKind) {
+			return true
+		}
+	}
+	return false
+}
+
+// typesPackage returns the DefinitelyTyped package declaring the types of an
+// npm package, ie. @types/lodash for lodash or @types/babel__core for @babel/core
+func typesPackage(name string) string {
+	if strings.HasPrefix(name, "@types/") {
+		return name
+	}
+	if strings.HasPrefix(name, "@") {
+		return "@types/" + strings.Replace(strings.TrimPrefix(name, "@"), "/", "__", 1)
+	}
+	return "@types/" + name
+}
+
+// lookupTypes returns the label of the @types package of an npm package, unless
+// the package is not installed or ships its own types
+func (lang *JS) lookupTypes(name string, c *config.Config, jsConfig *JsConfig) (string, bool) {
+	if lang.hasBundledTypes(name, c, jsConfig) {
+		return "", false
+	}
+	typesName := typesPackage(name)
+	typesFound, npmLabel, _ := lang.isNpmDependency(typesName, jsConfig)
+	if !typesFound {
+		return "", false
+	}
+	return npmLabel + typesName, true
+}
+
+// hasBundledTypes reports whether an installed npm package declares its own
+// types with the "types" or "typings" field of its package.json
+func (lang *JS) hasBundledTypes(name string, c *config.Config, jsConfig *JsConfig) bool {
+	if !jsConfig.BundledTypes {
+		return false
+	}
+	manifest := filepath.Join(c.RepoRoot, jsConfig.PackageDir, "node_modules", name, "package.json")
+	if bundled, ok := lang.bundledTypes[manifest]; ok {
+		return bundled
+	}
+	bundled := false
+	if data, err := os.ReadFile(manifest); err == nil {
+		pkg := struct {
+			Types   string `json:"types"`
+			Typings string `json:"typings"`
+		}{}
+		if err := json.Unmarshal(data, &pkg); err == nil {
+			bundled = pkg.Types != "" || pkg.Typings != ""
+		}
+	}
+	lang.bundledTypes[manifest] = bundled
+	return bundled
+}
+
 // https://nodejs.org/api/modules.html#modules_all_together
 func (lang *JS) isNpmDependency(imp string, jsConfig *JsConfig) (bool, string, bool) {
 
@@ -437,19 +744,8 @@ func (lang *JS) isNpmDependency(imp string, jsConfig *JsConfig) (bool, string, b
 		return false, "", false
 	}
 
-	// Grab the first part of the import (ie "foo/bar" -> "foo")
-	packageRoot := imp
-	for i := range imp {
-		if imp[i] == '/' {
-			prefix := imp[:i]
-			if prefix == "@types" {
-				continue
-			} else {
-				packageRoot = prefix
-				break
-			}
-		}
-	}
+	// Grab the package of the import (ie "foo/bar" -> "foo", "@foo/bar/baz" -> "@foo/bar")
+	packageRoot := npmPackageName(imp)
 
 	// Is the package root found in package.json ?
 	if npmLabel, ok := jsConfig.NpmDependencies.Dependencies[packageRoot]; ok {
@@ -469,6 +765,11 @@ func (lang *JS) isNpmDependency(imp string, jsConfig *JsConfig) (bool, string, b
 		return true, npmLabel, true
 	}
 
+	// Is it hoisted from the dependencies of other packages ?
+	if isHoisted(npmPackageName(imp), jsConfig) {
+		return true, jsConfig.DefaultNpmLabel, false
+	}
+
 	// Assume all @ imports are npm dependencies
 	if strings.HasPrefix(imp, "@types/") {
 		// Need to ignore @types/, since these are checked greedily
@@ -481,6 +782,160 @@ func (lang *JS) isNpmDependency(imp string, jsConfig *JsConfig) (bool, string, b
 	return false, "", false
 }
 
+// npmPackageName returns the package of an npm import, ie. "lodash" for
+// "lodash/merge" or "@babel/core" for "@babel/core/lib/parse".
+func npmPackageName(imp string) string {
+	s := strings.Split(imp, "/")
+	name := s[0]
+	if strings.HasPrefix(name, "@") && len(s) >= 2 {
+		name += "/" + s[1]
+	}
+	return name
+}
+
+// isHoisted reports whether an npm package that is not a dependency of the
+// package.json is installed transitively and hoisted by js_lockfile_hoist.
+func isHoisted(name string, jsConfig *JsConfig) bool {
+	if jsConfig.Lockfile == nil || !jsConfig.Lockfile.Packages[name] {
+		return false
+	}
+	for _, pattern := range jsConfig.HoistPatterns {
+		if matchImportPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInstalled reports npm packages that the js_lockfile does not install
+// for the workspace project of the js_package_file.
+func checkInstalled(name string, jsConfig *JsConfig, from label.Label) {
+	lockfile := jsConfig.Lockfile
+	if lockfile == nil || jsConfig.Quiet {
+		return
+	}
+	importer := lockfile.importer(jsConfig.PackageDir)
+	if lockfile.isInstalled(importer, name) || isHoisted(name, jsConfig) {
+		return
+	}
+	log.Print(Warn("[%s] npm package %s is not installed for %s by %s", from.Abs(from.Repo, from.Pkg).String(), name, importer, lockfile.Path))
+}
+
+// checkBoundaries reports dependencies on other packages of the repository
+// that violate the js_deny_deps and js_allow_deps patterns. It returns false
+// when the dependency must not be added.
+func checkBoundaries(dep string, imp string, jsConfig *JsConfig, from label.Label) bool {
+
+	if len(jsConfig.DenyDeps) == 0 && len(jsConfig.AllowDeps) == 0 {
+		return true
+	}
+
+	lbl, err := label.Parse(dep)
+	if err != nil {
+		return true
+	}
+	lbl = lbl.Abs(from.Repo, from.Pkg)
+	if (lbl.Repo != "" && lbl.Repo != from.Repo) || lbl.Pkg == from.Pkg || strings.HasPrefix(lbl.Name, "node_modules/") {
+		// npm packages, other repositories and the package itself are not bounded
+		return true
+	}
+
+	violation := ""
+	for _, pattern := range jsConfig.DenyDeps {
+		if matchImportPattern(pattern, lbl.Pkg) {
+			violation = "js_deny_deps " + pattern
+			break
+		}
+	}
+	if violation == "" && len(jsConfig.AllowDeps) > 0 {
+		violation = "js_allow_deps " + strings.Join(jsConfig.AllowDeps, " ")
+		for _, pattern := range jsConfig.AllowDeps {
+			if matchImportPattern(pattern, lbl.Pkg) {
+				violation = ""
+				break
+			}
+		}
+	}
+	if violation == "" {
+		return true
+	}
+
+	if !jsConfig.Quiet {
+		log.Print(Warn("[%s] import %v of %s is not allowed by %s", from.Abs(from.Repo, from.Pkg).String(), imp, graphLabel(lbl, from.Repo), violation))
+	}
+	return jsConfig.BoundaryViolations != "drop"
+}
+
+// isIgnoredImport reports whether imp matches one of the js_ignore_imports
+// patterns.
+func isIgnoredImport(imp string, jsConfig *JsConfig) bool {
+	for _, pattern := range jsConfig.IgnoreImports {
+		if matchImportPattern(pattern, imp) {
+			return true
+		}
+	}
+	return false
+}
+
+// findResolvePattern returns the label of the js_resolve pattern matching imp.
+// Patterns set last, ie. in child packages, take precedence.
+func findResolvePattern(imp string, jsConfig *JsConfig) (string, bool) {
+	for i := len(jsConfig.ResolvePatterns) - 1; i >= 0; i-- {
+		if matchImportPattern(jsConfig.ResolvePatterns[i].Pattern, imp) {
+			return jsConfig.ResolvePatterns[i].Label, true
+		}
+	}
+	return "", false
+}
+
+// resolveExternalRepo returns the label of an import in one of the
+// js_external_repo repositories, eg. "@acme/ui/button/Button" resolves to
+// "@acme_ui//src/button:Button" with "js_external_repo @acme/ui @acme_ui//src",
+// or to "@acme_ui//src/button/Button" in package mode. When several prefixes
+// match, the longest one wins.
+func resolveExternalRepo(imp string, jsConfig *JsConfig) (label.Label, bool) {
+
+	var match *ExternalRepo
+	for i, externalRepo := range jsConfig.ExternalRepos {
+		if imp != externalRepo.Prefix && !strings.HasPrefix(imp, externalRepo.Prefix+"/") {
+			continue
+		}
+		if match == nil || len(externalRepo.Prefix) >= len(match.Prefix) {
+			match = &jsConfig.ExternalRepos[i]
+		}
+	}
+	if match == nil {
+		return label.NoLabel, false
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(imp, match.Prefix), "/")
+	if rest != "" && !match.PerPackage {
+		// a rule per file, named after the file
+		pkg := path.Join(match.Root, path.Dir(rest))
+		if pkg == "." {
+			pkg = ""
+		}
+		return label.New(match.Repo, pkg, trimExt(path.Base(rest))), true
+	}
+
+	// the package itself, as a barrel or collected rule
+	pkg := path.Join(match.Root, rest)
+	if pkg == "." || pkg == "" {
+		return label.New(match.Repo, "", match.Repo), true
+	}
+	return label.New(match.Repo, pkg, path.Base(pkg)), true
+}
+
+// matchImportPattern reports whether imp matches pattern, either exactly or as
+// a glob. A trailing "/**" matches everything below a prefix.
+func matchImportPattern(pattern string, imp string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return imp == prefix || strings.HasPrefix(imp, prefix+"/")
+	}
+	matched, _ := path.Match(pattern, imp)
+	return matched
+}
+
 func hasPrefix(suffixes []string, x string) bool {
 	for _, suffix := range suffixes {
 		if strings.HasPrefix(x, suffix) {
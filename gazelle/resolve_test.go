@@ -0,0 +1,100 @@
+// Copyright 2019 The Bazel Authors. All rights reserved.
+// Modifications copyright (C) 2021 BenchSci Analytics Inc.
+// Modifications copyright (C) 2018 Ecosia GmbH
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+)
+
+func TestTypesPackage(t *testing.T) {
+	for _, tc := range []struct {
+		desc, name, want string
+	}{
+		{
+			desc: "unscoped",
+			name: "lodash",
+			want: "@types/lodash",
+		}, {
+			desc: "scoped",
+			name: "@babel/core",
+			want: "@types/babel__core",
+		}, {
+			desc: "types",
+			name: "@types/node",
+			want: "@types/node",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := typesPackage(tc.name); got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookupTypes(t *testing.T) {
+	root := t.TempDir()
+	for name, manifest := range map[string]string{
+		"zod":          `{"name": "zod", "types": "./index.d.ts"}`,
+		"@scope/typed": `{"name": "@scope/typed", "typings": "lib/index.d.ts"}`,
+		"lodash":       `{"name": "lodash"}`,
+	} {
+		dir := filepath.Join(root, "web", "node_modules", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &config.Config{RepoRoot: root}
+	jsConfig := NewJsConfig()
+	jsConfig.PackageDir = "web"
+	jsConfig.BundledTypes = true
+	for _, name := range []string{"@types/zod", "@types/scope__typed", "@types/lodash"} {
+		jsConfig.NpmDependencies.DevDependencies[name] = "//web:node_modules/"
+	}
+	lang := NewLanguage().(*JS)
+
+	for _, tc := range []struct {
+		desc, name, want string
+	}{
+		{
+			desc: "bundled types",
+			name: "zod",
+		}, {
+			desc: "bundled typings",
+			name: "@scope/typed",
+		}, {
+			desc: "no bundled types",
+			name: "lodash",
+			want: "//web:node_modules/@types/lodash",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, _ := lang.lookupTypes(tc.name, c, jsConfig)
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+"""css_module
+
+Macro for the CSS modules imported by TypeScript sources, generated from
+*.module.{css,scss,sass,less} files. Map it to a rule generating exact
+typings for the class names with gazelle's map_kind directive.
+"""
+load("@aspect_rules_js//js:defs.bzl", "js_library")
+
+def css_module(name, srcs = [], **kwargs):
+    """Wraps CSS modules into a js_library, with a generic .d.ts typing for each of them.
+
+    The typing declares the default export as a map of any class name, which
+    lets `import styles from "./a.module.css"` compile without checking names.
+    """
+    typings = []
+    for i, src in enumerate(srcs):
+        typing = src + ".d.ts"
+        native.genrule(
+            name = "%s_typings_%d" % (name, i),
+            outs = [typing],
+            cmd = "echo 'declare const classes: { readonly [key: string]: string }; export default classes;' > $@",
+            visibility = ["//visibility:private"],
+        )
+        typings.append(typing)
+    js_library(
+        name = name,
+        srcs = srcs + typings,
+        **kwargs
+    )
@@ -0,0 +1,23 @@
+"""playwright_test and cypress_test
+
+Placeholder macros for the end-to-end test suites generated from *.e2e and
+*.cy files. Map them to the rules running your tests with gazelle's map_kind
+directive.
+"""
+load("@aspect_rules_js//js:defs.bzl", "js_library")
+
+def playwright_test(**kwargs):
+    """Collects a Playwright spec, its deps and playwright.config into a testonly js_library.
+
+    Running the spec requires a Playwright test rule, eg.
+    `# gazelle:map_kind playwright_test playwright_test //bazel:playwright.bzl`.
+    """
+    js_library(testonly = True, **kwargs)
+
+def cypress_test(**kwargs):
+    """Collects a Cypress spec, its deps and cypress.config into a testonly js_library.
+
+    Running the spec requires a Cypress test rule, eg.
+    `# gazelle:map_kind cypress_test cypress_test //bazel:cypress.bzl`.
+    """
+    js_library(testonly = True, **kwargs)
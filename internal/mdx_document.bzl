@@ -0,0 +1,13 @@
+"""mdx_document
+
+Placeholder macro for the MDX documents generated from .mdx files. Map it to
+the rule compiling your documents with gazelle's map_kind directive.
+"""
+load("@aspect_rules_js//js:defs.bzl", "js_library")
+
+def mdx_document(**kwargs):
+    """Collects uncompiled MDX documents and the modules they import into a js_library.
+
+    The .mdx sources are left for a bundler or a docs site to compile.
+    """
+    js_library(**kwargs)
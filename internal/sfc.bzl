@@ -0,0 +1,21 @@
+"""vue_component and svelte_component
+
+Placeholder macros for the single-file components generated from .vue and
+.svelte files. Map them to the rules compiling your components with gazelle's
+map_kind directive.
+"""
+load("@aspect_rules_js//js:defs.bzl", "js_library")
+
+def vue_component(**kwargs):
+    """Collects uncompiled Vue components and their deps into a js_library.
+
+    The .vue sources are left for a bundler like Vite to compile.
+    """
+    js_library(**kwargs)
+
+def svelte_component(**kwargs):
+    """Collects uncompiled Svelte components and their deps into a js_library.
+
+    The .svelte sources are left for a bundler like Vite to compile.
+    """
+    js_library(**kwargs)
@@ -0,0 +1,15 @@
+"""storybook_stories
+
+Placeholder macro for the stories of a package, generated from *.stories
+files. Map it to the rule building your Storybook with gazelle's map_kind
+directive.
+"""
+load("@aspect_rules_js//js:defs.bzl", "js_library")
+
+def storybook_stories(**kwargs):
+    """Groups the stories of a package and the components they render into a js_library.
+
+    A Storybook build can depend on these targets to find every story of the
+    repository.
+    """
+    js_library(**kwargs)
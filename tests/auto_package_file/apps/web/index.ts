@@ -0,0 +1,4 @@
+import { useMemo } from "react";
+import { sortBy } from "lodash";
+
+export const useSorted = <T>(items: T[]) => useMemo(() => sortBy(items), [items]);
@@ -0,0 +1,3 @@
+import { sortBy } from "lodash";
+
+export const sorted = sortBy([3, 1, 2]);
@@ -0,0 +1,4 @@
+import { normalize } from "../lib/strings"
+import { trim } from "../lib/trim"
+
+export const main = normalize(trim(" App "))
@@ -0,0 +1 @@
+export const trim = (value: string) => value.trim()
@@ -0,0 +1,3 @@
+import { normalize } from "../lib/strings"
+
+console.log(normalize(process.argv[2]))
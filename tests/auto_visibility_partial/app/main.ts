@@ -0,0 +1,3 @@
+import { normalize } from "../lib/strings"
+
+export const main = normalize(" App ")
@@ -0,0 +1,3 @@
+import { trim } from "./trim"
+
+export const normalize = (value: string) => trim(value).toLowerCase()
@@ -0,0 +1,3 @@
+import { format } from "../ui"
+
+export const main = format(" App ")
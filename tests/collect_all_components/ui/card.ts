@@ -0,0 +1,4 @@
+import styles from "./Card.module.css"
+import { format } from "./format"
+
+export const card = (title: string) => `<div class="${styles.card}">${format(title)}</div>`
@@ -0,0 +1,5 @@
+import { some_var } from "./index"
+
+it("matches", () => {
+    expect(some_var).toMatchSnapshot()
+})
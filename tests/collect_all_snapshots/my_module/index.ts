@@ -0,0 +1 @@
+export var some_var = "Hello"
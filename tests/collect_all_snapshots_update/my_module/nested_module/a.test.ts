@@ -0,0 +1,5 @@
+import { other_var } from "./index"
+
+it("matches", () => {
+    expect(other_var).toMatchSnapshot()
+})
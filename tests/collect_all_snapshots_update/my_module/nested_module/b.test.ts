@@ -0,0 +1,5 @@
+import { other_var } from "./index"
+
+it("equals", () => {
+    expect(other_var).toBe("World")
+})
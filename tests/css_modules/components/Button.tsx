@@ -0,0 +1,4 @@
+import styles from './Button.module.scss'
+import './global.css'
+
+export const Button = ({ label }: { label: string }) => <button className={styles.button}>{label}</button>
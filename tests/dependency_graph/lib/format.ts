@@ -0,0 +1,3 @@
+import { capitalize } from "lodash"
+
+export const format = (value: string) => capitalize(value)
@@ -0,0 +1,2 @@
+export { format } from "./format"
+export const parse = (input: string) => input.trim()
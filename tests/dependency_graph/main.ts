@@ -0,0 +1,4 @@
+import { format } from "./lib/format"
+import { parse } from "./lib"
+
+export const main = (input: string) => format(parse(input))
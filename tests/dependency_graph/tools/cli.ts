@@ -0,0 +1,3 @@
+import { main } from "../main"
+
+console.log(main(process.argv[2]))
@@ -0,0 +1,7 @@
+import { LOGIN } from '../src/routes'
+
+describe('login', () => {
+    it('renders', () => {
+        cy.visit(LOGIN)
+    })
+})
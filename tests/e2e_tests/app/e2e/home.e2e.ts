@@ -0,0 +1,7 @@
+import { test, expect } from '@playwright/test'
+import { HOME } from '../src/routes'
+
+test('home page', async ({ page }) => {
+    await page.goto(HOME)
+    await expect(page).toHaveTitle(/Home/)
+})
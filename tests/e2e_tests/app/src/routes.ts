@@ -0,0 +1,2 @@
+export const HOME = '/'
+export const LOGIN = '/login'
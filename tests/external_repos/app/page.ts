@@ -0,0 +1,7 @@
+import { Button } from "@acme/ui/button/Button"
+import { theme } from "@acme/ui/theme.ts"
+import { ArrowIcon } from "@acme/ui/icons/arrows"
+import * as icons from "@acme/ui/icons"
+import { debounce } from "@acme/utils"
+
+export const page = debounce(() => new Button({ icon: ArrowIcon, icons, theme }))
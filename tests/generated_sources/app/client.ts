@@ -0,0 +1,6 @@
+import { User } from "../proto/user_pb"
+import { UserService } from "../proto/user_connect"
+import { UserQuery } from "../graphql/generated"
+import introspection from "../graphql/generated/schema.json"
+
+export const client = { User, UserService, UserQuery, introspection }
@@ -0,0 +1,3 @@
+const context = require.context('./icons', false, /\.svg$/)
+
+module.exports = context.keys().map(context)
@@ -0,0 +1,3 @@
+const pages = import.meta.glob(['./pages/*.ts', '!**/draft.ts'])
+
+export const routes = Object.keys(pages)
@@ -0,0 +1 @@
+export const layout = "default"
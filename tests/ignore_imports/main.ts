@@ -0,0 +1,9 @@
+import { registerSW } from "virtual:pwa-register"
+import { Inter } from "next/font/google"
+import { page } from "$app/stores"
+import { layout } from "./layout"
+
+registerSW({ immediate: true })
+export const font = Inter({ subsets: ["latin"] })
+export const current = page
+export default layout
@@ -0,0 +1,4 @@
+import { navigating } from "$app/stores"
+import { layout } from "../layout"
+
+export const load = () => ({ layout, navigating })
@@ -0,0 +1,4 @@
+import { removed } from "./missing"
+import { layout } from "../../layout"
+
+export const page = { layout, removed }
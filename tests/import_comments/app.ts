@@ -0,0 +1,11 @@
+import { fetchUser } from "#server/api" // gazelle:resolve //server:api
+import { helper } from "./helper"
+
+let instrumentation
+try {
+  instrumentation = require("optional-instrumentation") // gazelle:ignore
+} catch {
+  instrumentation = undefined
+}
+
+export const user = fetchUser(helper())
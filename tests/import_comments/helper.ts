@@ -0,0 +1 @@
+export const helper = () => "id"
@@ -0,0 +1 @@
+export const fetchUser = (id: string) => ({ id })
@@ -0,0 +1,3 @@
+import { a } from "../a/a";
+
+export const b = () => a;
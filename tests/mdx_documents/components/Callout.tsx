@@ -0,0 +1 @@
+export const Callout = ({ children }: { children: string }) => <aside>{children}</aside>
@@ -0,0 +1,4 @@
+import { button } from "../../packages/ui/button"
+import { config } from "./config"
+
+export const app = { button, config }
@@ -0,0 +1 @@
+export const state = { count: 0 }
@@ -0,0 +1,4 @@
+import { config } from "../../apps/web/config"
+import { clamp } from "../utils/math"
+
+export const button = clamp(config.size, 0, 10)
@@ -0,0 +1,4 @@
+import { state } from "../client/state"
+import { clamp } from "../packages/utils/math"
+
+export const api = clamp(state.count, 0, 100)
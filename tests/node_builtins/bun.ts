@@ -0,0 +1,5 @@
+import { Database } from "bun:sqlite";
+import { serve } from "bun";
+
+const db = new Database(":memory:");
+export const server = serve({ fetch: () => new Response(JSON.stringify(db.query("select 1").get())) });
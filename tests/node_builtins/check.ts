@@ -0,0 +1,5 @@
+import { test } from "node:test";
+import assert from "node:assert/strict";
+import { read } from "./server";
+
+export const check = (file: string) => test(file, async () => assert.ok(await read(file)));
@@ -0,0 +1,6 @@
+import { readFile } from "fs/promises";
+import { ReadableStream } from "stream/web";
+import { channel } from "diagnostics_channel";
+
+export const read = async (file: string) => new ReadableStream({ start: async (c) => c.enqueue(await readFile(file)) });
+export const requests = channel("requests");
@@ -0,0 +1,3 @@
+import { merge } from "lodash";
+
+export const config = merge({}, { debug: false });
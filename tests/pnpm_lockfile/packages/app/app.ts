@@ -0,0 +1,5 @@
+import { useState } from "react";
+import jsTokens from "js-tokens";
+import { merge } from "lodash";
+
+export const useTokens = (code: string) => useState(merge({}, Array.from(jsTokens(code))));
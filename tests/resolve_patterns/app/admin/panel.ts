@@ -0,0 +1,4 @@
+import { Role } from "@generated/proto/admin/role_pb"
+import { User } from "@generated/proto/user_pb"
+
+export const panel = (user: User) => Role.ADMIN
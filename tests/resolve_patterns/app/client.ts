@@ -0,0 +1,6 @@
+import { User } from "@generated/proto/user_pb"
+import { UserServiceClient } from "@generated/proto/services/user_grpc_web_pb"
+import logo from "#icons/logo.svg"
+
+export const client = new UserServiceClient(logo)
+export type { User }
@@ -0,0 +1,4 @@
+import { missing } from "./missing";
+import { util } from "./util";
+
+export const main = util(missing);
@@ -0,0 +1 @@
+/// <reference types="@babel/core" />
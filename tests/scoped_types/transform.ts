@@ -0,0 +1,6 @@
+import { transformSync } from "@babel/core";
+import styled from "@emotion/styled";
+import { merge } from "lodash";
+
+export const transform = (code: string) => transformSync(code, merge({}, { babelrc: false }));
+export const Box = styled.div``;
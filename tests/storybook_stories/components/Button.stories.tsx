@@ -0,0 +1,7 @@
+import type { Meta, StoryObj } from '@storybook/react'
+import { Button } from './Button'
+
+const meta: Meta<typeof Button> = { component: Button }
+export default meta
+
+export const Primary: StoryObj<typeof Button> = { args: { label: 'Button' } }
@@ -63,6 +63,7 @@ func RunTest(t *testing.T, name string) {
 
 	var inputs []testtools.FileSpec
 	var goldens []testtools.FileSpec
+	args := []string{"-build_file_name=BUILD,BUILD.bazel"}
 
 	// Get the path to the test directory
 	testDirShortPath := testDataPath + name
@@ -93,8 +94,10 @@ func RunTest(t *testing.T, name string) {
 			t.Errorf("os.ReadFile(%q) error: %v", f.Path, err)
 		}
 
-		// Add file to inputs or goldens
-		if strings.HasSuffix(testFilePath, ".in") {
+		// Add file to inputs or goldens, or its lines to the gazelle arguments
+		if testFilePath == "/arguments.txt" {
+			args = append(args, strings.Fields(string(content))...)
+		} else if strings.HasSuffix(testFilePath, ".in") {
 			inputs = append(inputs, testtools.FileSpec{
 				Path:    filepath.Join(name, strings.TrimSuffix(testFilePath, ".in")),
 				Content: string(content),
@@ -133,7 +136,6 @@ func RunTest(t *testing.T, name string) {
 
 	// Run gazelle
 	workspaceRoot := filepath.Join(testdataDir, name)
-	args := []string{"-build_file_name=BUILD,BUILD.bazel"}
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, gazellePath, args...)
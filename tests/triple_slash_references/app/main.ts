@@ -0,0 +1,5 @@
+/// <reference types="node" />
+/// <reference path="../types/globals.d.ts" />
+/// <reference path="env.d.ts" />
+
+export const version = `${__APP_VERSION__} ${process.version}`
@@ -0,0 +1,4 @@
+const worker = new Worker(new URL('./worker.ts', import.meta.url), { type: 'module' })
+const wasm = await WebAssembly.instantiateStreaming(fetch(new URL('./module.wasm', import.meta.url)))
+
+worker.postMessage(wasm.instance.exports)
@@ -0,0 +1 @@
+self.onmessage = (event) => console.log(event.data)
@@ -0,0 +1,4 @@
+import React from "react";
+import { Button } from "@acme/ui/Button";
+
+export const App = () => <Button label="Hello" />;
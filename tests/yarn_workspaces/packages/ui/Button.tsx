@@ -0,0 +1,3 @@
+import React from "react";
+
+export const Button = ({ label }: { label: string }) => <button>{label}</button>;